@@ -0,0 +1,267 @@
+package gobrake
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// aggregate holds stats of one kind, e.g. route stats, collected by one
+// shard during one window.
+type aggregate interface {
+	// merge adds other, an aggregate of the same kind, to the aggregate.
+	merge(other aggregate) error
+}
+
+// aggregator collects stats of one kind in per-CPU shards, so concurrent
+// requests don't contend for the same lock. Shards are merged when the
+// window is flushed.
+type aggregator struct {
+	name  string
+	sched *apmScheduler
+
+	newAggregate func() aggregate
+	send         func(aggregate) error
+
+	shards []aggregatorShard
+
+	// Keys added during the current window when APMMaxKeys is set.
+	keysMu sync.RWMutex
+	keys   map[interface{}]struct{}
+}
+
+// Identifies stats aggregated beyond APMMaxKeys.
+const otherKey = "other"
+
+type aggregatorShard struct {
+	mu  sync.Mutex
+	agg aggregate
+
+	// Keeps shards on separate cache lines.
+	_ [40]byte
+}
+
+// add calls fn with the aggregate of the current shard. The shard is locked
+// while fn runs. Stats are dropped once the scheduler is closed.
+func (a *aggregator) add(fn func(aggregate) error) error {
+	sh := &a.shards[shardIndex()&uint32(len(a.shards)-1)]
+
+	sh.mu.Lock()
+	// Checked under the shard lock, so stats are either flushed by close or
+	// dropped.
+	if a.sched.isClosed() {
+		sh.mu.Unlock()
+		return errClosed
+	}
+	if sh.agg == nil {
+		sh.agg = a.newAggregate()
+	}
+	err := fn(sh.agg)
+	sh.mu.Unlock()
+
+	a.sched.schedule()
+	return err
+}
+
+// admit reports whether stats of key, e.g. a route or a query, that are new
+// to the shard can be added to the window. key is the capped dimension
+// rather than the aggregation key, so time buckets and status codes of a
+// route count once. When max is positive and the window already has max
+// keys, stats must be added under otherKey instead, which is always admitted.
+func (a *aggregator) admit(key interface{}, max int) bool {
+	if max <= 0 {
+		return true
+	}
+
+	a.keysMu.RLock()
+	_, ok := a.keys[key]
+	n := len(a.keys)
+	a.keysMu.RUnlock()
+	if ok {
+		return true
+	}
+	if n >= max {
+		return false
+	}
+
+	a.keysMu.Lock()
+	defer a.keysMu.Unlock()
+
+	if _, ok := a.keys[key]; ok {
+		return true
+	}
+	if len(a.keys) >= max {
+		return false
+	}
+	if a.keys == nil {
+		a.keys = make(map[interface{}]struct{})
+	}
+	a.keys[key] = struct{}{}
+	return true
+}
+
+// flush merges the shards and sends the result.
+func (a *aggregator) flush() {
+	a.keysMu.Lock()
+	a.keys = nil
+	a.keysMu.Unlock()
+
+	var merged aggregate
+	for i := range a.shards {
+		sh := &a.shards[i]
+
+		sh.mu.Lock()
+		agg := sh.agg
+		sh.agg = nil
+		sh.mu.Unlock()
+
+		if agg == nil {
+			continue
+		}
+		if merged == nil {
+			merged = agg
+			continue
+		}
+		if err := merged.merge(agg); err != nil {
+			a.sched.opts.Load().log().Error(a.name+".merge failed", "error", err)
+		}
+	}
+
+	if merged == nil {
+		return
+	}
+
+	if err := a.send(merged); err != nil {
+		a.sched.opts.Load().log().Error(a.name+".send failed", "error", err)
+	}
+}
+
+var (
+	nextShardIndex uint32 // atomic
+
+	// sync.Pool keeps a cache per P, so goroutines running on the same P
+	// usually get the same shard index.
+	shardIndexes = sync.Pool{
+		New: func() interface{} {
+			i := atomic.AddUint32(&nextShardIndex, 1)
+			return &i
+		},
+	}
+)
+
+func shardIndex() uint32 {
+	p := shardIndexes.Get().(*uint32)
+	i := *p
+	shardIndexes.Put(p)
+	return i
+}
+
+//------------------------------------------------------------------------------
+
+// apmScheduler flushes all aggregators of a notifier at the end of every
+// flush period. Periods are aligned to the wall clock, so windows of all
+// stats end at the same time, e.g. every 15 seconds past the minute.
+type apmScheduler struct {
+	opts *sharedOptions
+
+	// scheduled is 1 when a flush is scheduled.
+	scheduled uint32 // atomic
+
+	// closed is 1 when the scheduler is closed.
+	closed uint32 // atomic
+
+	mu    sync.Mutex
+	aggs  []*aggregator
+	timer *time.Timer
+}
+
+func newAPMScheduler(opts *sharedOptions) *apmScheduler {
+	return &apmScheduler{
+		opts: opts,
+	}
+}
+
+// newAggregator returns an aggregator flushed by the scheduler. name is used
+// in log messages.
+func (s *apmScheduler) newAggregator(
+	name string, newAggregate func() aggregate, send func(aggregate) error,
+) *aggregator {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n *= 2
+	}
+
+	a := &aggregator{
+		name:         name,
+		sched:        s,
+		newAggregate: newAggregate,
+		send:         send,
+		shards:       make([]aggregatorShard, n),
+	}
+
+	s.mu.Lock()
+	s.aggs = append(s.aggs, a)
+	s.mu.Unlock()
+
+	return a
+}
+
+// schedule schedules a flush at the end of the current period unless one is
+// already scheduled.
+func (s *apmScheduler) schedule() {
+	if atomic.LoadUint32(&s.scheduled) == 1 {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&s.scheduled, 0, 1) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isClosed() {
+		return
+	}
+	period := s.opts.Load().apmFlushPeriod()
+	now := time.Now()
+	end := now.Truncate(period).Add(period)
+	s.timer = time.AfterFunc(end.Sub(now), s.flush)
+}
+
+// flush flushes all aggregators.
+func (s *apmScheduler) flush() {
+	// Stats added after this point schedule the next flush.
+	atomic.StoreUint32(&s.scheduled, 0)
+
+	s.mu.Lock()
+	aggs := s.aggs
+	s.mu.Unlock()
+
+	for _, a := range aggs {
+		a.flush()
+	}
+}
+
+// close stops the scheduler and flushes pending stats. Stats added after
+// close are dropped.
+func (s *apmScheduler) close() {
+	s.mu.Lock()
+	atomic.StoreUint32(&s.closed, 1)
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.mu.Unlock()
+
+	s.flush()
+}
+
+func (s *apmScheduler) isClosed() bool {
+	return atomic.LoadUint32(&s.closed) == 1
+}
+
+// bucketTime returns the start of the time bucket that stats started at t
+// are aggregated in.
+func bucketTime(t time.Time, resolution time.Duration) time.Time {
+	return t.UTC().Truncate(resolution)
+}
@@ -0,0 +1,371 @@
+package gobrake
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("aggregator", func() {
+	var sched *apmScheduler
+	var agg *aggregator
+
+	var mu sync.Mutex
+	var sent []routeStatsAggregate
+
+	BeforeEach(func() {
+		sent = nil
+		sched = newAPMScheduler(newSharedOptions(&NotifierOptions{
+			APMFlushPeriod: time.Hour,
+		}))
+		agg = sched.newAggregator("test", func() aggregate {
+			return make(routeStatsAggregate)
+		}, func(a aggregate) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, a.(routeStatsAggregate))
+			return nil
+		})
+		agg.shards = make([]aggregatorShard, 4)
+	})
+
+	AfterEach(func() {
+		sched.close()
+	})
+
+	key := routeKey{
+		Method: "GET",
+		Route:  "/ping",
+		Time:   bucketTime(time.Now(), time.Minute),
+	}
+
+	addToShard := func(i int, durs ...time.Duration) {
+		stat := newTDigestStat()
+		for _, dur := range durs {
+			Expect(stat.add(dur)).To(Succeed())
+		}
+		agg.shards[i].agg = routeStatsAggregate{key: stat}
+	}
+
+	It("merges shards on flush", func() {
+		addToShard(0, time.Millisecond)
+		addToShard(2, 2*time.Millisecond, 3*time.Millisecond)
+
+		agg.flush()
+
+		Expect(sent).To(HaveLen(1))
+		Expect(sent[0]).To(HaveLen(1))
+		stat := sent[0][key]
+		Expect(stat.Count).To(Equal(3))
+		Expect(stat.Sum).To(Equal(6.0))
+		Expect(stat.Sumsq).To(Equal(14.0))
+		Expect(stat.td.Count()).To(Equal(uint64(3)))
+
+		for i := range agg.shards {
+			Expect(agg.shards[i].agg).To(BeNil())
+		}
+	})
+
+	It("does not send empty windows", func() {
+		agg.flush()
+		Expect(sent).To(BeEmpty())
+	})
+
+	It("adds stats concurrently", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					err := agg.add(func(a aggregate) error {
+						m := a.(routeStatsAggregate)
+						stat, ok := m[key]
+						if !ok {
+							stat = newTDigestStat()
+							m[key] = stat
+						}
+						return stat.add(time.Millisecond)
+					})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}()
+		}
+		wg.Wait()
+
+		agg.flush()
+
+		Expect(sent).To(HaveLen(1))
+		Expect(sent[0][key].Count).To(Equal(800))
+	})
+
+	It("flushes all aggregators of the scheduler on close", func() {
+		var other []aggregate
+		otherAgg := sched.newAggregator("other", func() aggregate {
+			return make(queryStatsAggregate)
+		}, func(a aggregate) error {
+			other = append(other, a)
+			return nil
+		})
+
+		addToShard(1, time.Millisecond)
+		Expect(otherAgg.add(func(a aggregate) error {
+			a.(queryStatsAggregate)[queryKey{Query: "SELECT 1"}] = newTDigestStat()
+			return nil
+		})).To(Succeed())
+
+		sched.close()
+
+		Expect(sent).To(HaveLen(1))
+		Expect(other).To(HaveLen(1))
+	})
+
+	It("drops stats added after close", func() {
+		sched.close()
+
+		err := agg.add(func(a aggregate) error {
+			a.(routeStatsAggregate)[key] = newTDigestStat()
+			return nil
+		})
+		Expect(err).To(Equal(errClosed))
+		Expect(atomic.LoadUint32(&sched.scheduled)).To(BeZero())
+		for i := range agg.shards {
+			Expect(agg.shards[i].agg).To(BeNil())
+		}
+	})
+})
+
+var _ = Describe("tdigestStatGroups", func() {
+	It("merges groups", func() {
+		var b1, b2 tdigestStatGroups
+		b1.add(3*time.Millisecond, map[string]time.Duration{
+			"sql": time.Millisecond,
+		})
+		b2.add(5*time.Millisecond, map[string]time.Duration{
+			"sql":  2 * time.Millisecond,
+			"http": 3 * time.Millisecond,
+		})
+
+		Expect(b1.merge(&b2)).To(Succeed())
+
+		Expect(b1.Count).To(Equal(2))
+		Expect(b1.Sum).To(Equal(8.0))
+		Expect(b1.Groups).To(HaveLen(2))
+		Expect(b1.Groups["sql"].Count).To(Equal(2))
+		Expect(b1.Groups["sql"].Sum).To(Equal(3.0))
+		Expect(b1.Groups["http"].Count).To(Equal(1))
+	})
+})
+
+var _ = Describe("APM stats limits", func() {
+	var opt *NotifierOptions
+	var sched *apmScheduler
+
+	var mu sync.Mutex
+	var sent []aggregate
+
+	capture := func(agg *aggregator) {
+		agg.send = func(a aggregate) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, a)
+			return nil
+		}
+	}
+
+	BeforeEach(func() {
+		clock = fakeClock
+		sent = nil
+		opt = &NotifierOptions{
+			APMFlushPeriod: time.Hour,
+			APMMaxKeys:     2,
+		}
+	})
+
+	JustBeforeEach(func() {
+		sched = newAPMScheduler(newSharedOptions(opt))
+	})
+
+	AfterEach(func() {
+		sched.close()
+		clock = realClock
+	})
+
+	notifyRoute := func(notify func(context.Context, *RouteMetric) error, route string, status int) {
+		c, metric := NewRouteMetric(context.Background(), "GET", route)
+		metric.StatusCode = status
+		metric.finish()
+		Expect(notify(c, metric)).To(Succeed())
+	}
+
+	It("counts routes instead of status codes and time buckets", func() {
+		s := newRouteStats(sched.opts, nil, sched)
+		capture(s.agg)
+
+		for _, status := range []int{200, 201, 404, 500} {
+			notifyRoute(s.Notify, "/a", status)
+		}
+		fakeClock.Advance(time.Minute)
+		notifyRoute(s.Notify, "/a", 200)
+		notifyRoute(s.Notify, "/b", 200)
+		notifyRoute(s.Notify, "/c", 200)
+		s.Flush()
+
+		Expect(sent).To(HaveLen(1))
+		routes := make(map[string]int)
+		for k, stat := range sent[0].(routeStatsAggregate) {
+			routes[k.Route] += stat.Count
+		}
+		Expect(routes).To(Equal(map[string]int{"/a": 5, "/b": 1, "other": 1}))
+	})
+
+	It("aggregates route breakdowns beyond the limit under other", func() {
+		s := newRouteBreakdowns(sched.opts, nil, sched)
+		capture(s.agg)
+
+		notifyRoute(s.Notify, "/a", 200)
+		notifyRoute(s.Notify, "/a", 404)
+		notifyRoute(s.Notify, "/b", 200)
+		notifyRoute(s.Notify, "/c", 200)
+		notifyRoute(s.Notify, "/d", 200)
+		s.Flush()
+
+		Expect(sent).To(HaveLen(1))
+		routes := make(map[string]int)
+		for k, b := range sent[0].(routeBreakdownsAggregate) {
+			routes[k.Route] += b.Count
+		}
+		Expect(routes).To(Equal(map[string]int{"/a": 2, "/b": 1, "other": 2}))
+	})
+
+	It("aggregates queries beyond the limit under other", func() {
+		s := newQueryStats(sched.opts, nil, sched)
+		capture(s.agg)
+
+		notifyQuery := func(route, query string) {
+			now := clock.Now()
+			Expect(s.Notify(context.Background(), &QueryInfo{
+				Method:    "GET",
+				Route:     route,
+				Query:     query,
+				StartTime: now,
+				EndTime:   now.Add(time.Millisecond),
+			})).To(Succeed())
+		}
+		notifyQuery("/a", "SELECT 1")
+		notifyQuery("/b", "SELECT 1")
+		notifyQuery("/a", "SELECT 2")
+		notifyQuery("/a", "SELECT 3")
+		notifyQuery("/b", "SELECT 4")
+		s.flush()
+
+		Expect(sent).To(HaveLen(1))
+		queries := make(map[queryKey]int)
+		for k, stat := range sent[0].(queryStatsAggregate) {
+			k.Time = time.Time{}
+			queries[k] = stat.Count
+		}
+		Expect(queries).To(Equal(map[queryKey]int{
+			{Method: "GET", Route: "/a", Query: "SELECT 1"}: 1,
+			{Method: "GET", Route: "/b", Query: "SELECT 1"}: 1,
+			{Method: "GET", Route: "/a", Query: "SELECT 2"}: 1,
+			{Query: "other"}: 2,
+		}))
+	})
+
+	It("aggregates queues beyond the limit under other", func() {
+		s := newQueueStats(sched.opts, nil, sched)
+		capture(s.agg)
+
+		for _, queue := range []string{"a", "b", "a", "c", "d"} {
+			_, metric := NewQueueMetric(context.Background(), queue)
+			Expect(s.Notify(context.Background(), metric)).To(Succeed())
+		}
+		s.flush()
+
+		Expect(sent).To(HaveLen(1))
+		queues := make(map[string]int)
+		for k, b := range sent[0].(queueStatsAggregate) {
+			queues[k.Queue] = b.Count
+		}
+		Expect(queues).To(Equal(map[string]int{"a": 2, "b": 1, "other": 2}))
+	})
+
+	Context("when EnableRouteNormalization is set", func() {
+		BeforeEach(func() {
+			opt.APMMaxKeys = 0
+			opt.RouteTemplates = []string{"/users/:name"}
+			opt.EnableRouteNormalization = true
+		})
+
+		It("reports the same routes in route and query stats", func() {
+			rs := newRoutes(sched.opts, nil, sched)
+			qs := newQueryStats(sched.opts, nil, sched)
+			capture(rs.stats.agg)
+			capture(rs.breakdowns.agg)
+			capture(qs.agg)
+
+			for _, route := range []string{"/users/alice", "/posts/1"} {
+				notifyRoute(rs.Notify, route, 200)
+
+				now := clock.Now()
+				Expect(qs.Notify(context.Background(), &QueryInfo{
+					Method:    "GET",
+					Route:     route,
+					Query:     "SELECT 1",
+					StartTime: now,
+					EndTime:   now.Add(time.Millisecond),
+				})).To(Succeed())
+			}
+			rs.Flush()
+			qs.flush()
+
+			Expect(sent).To(HaveLen(3))
+			for _, agg := range sent {
+				routes := make(map[string]bool)
+				switch agg := agg.(type) {
+				case routeStatsAggregate:
+					for k := range agg {
+						routes[k.Route] = true
+					}
+				case routeBreakdownsAggregate:
+					for k := range agg {
+						routes[k.Route] = true
+					}
+				case queryStatsAggregate:
+					for k := range agg {
+						routes[k.Route] = true
+					}
+				}
+				Expect(routes).To(Equal(map[string]bool{
+					"/users/:name": true,
+					"/posts/:id":   true,
+				}))
+			}
+		})
+	})
+
+	Context("when APMBucketResolution is set", func() {
+		BeforeEach(func() {
+			opt.APMMaxKeys = 0
+			opt.APMBucketResolution = time.Hour
+		})
+
+		It("truncates route time to the resolution", func() {
+			s := newRouteStats(sched.opts, nil, sched)
+			capture(s.agg)
+
+			notifyRoute(s.Notify, "/ping", 200)
+			s.Flush()
+
+			Expect(sent).To(HaveLen(1))
+			for k := range sent[0].(routeStatsAggregate) {
+				Expect(k.Time).To(Equal(fakeClock.Now().UTC().Truncate(time.Hour)))
+			}
+		})
+	})
+})
@@ -13,13 +13,22 @@ type Handler struct {
 	Gobrake         *gobrake.Notifier
 	HandlerSeverity log.Level
 	depth           int
+
+	breadcrumbs     bool
+	breadcrumbLevel log.Level
 }
 
 func NewLogger(h *Handler) (*Handler, error) {
 	if h.Gobrake == nil {
 		return h, errors.New("airbrake notifier not defined")
 	}
-	h = &Handler{h.Gobrake, h.HandlerSeverity, h.depth}
+	h = &Handler{
+		Gobrake:         h.Gobrake,
+		HandlerSeverity: h.HandlerSeverity,
+		depth:           h.depth,
+		breadcrumbs:     h.breadcrumbs,
+		breadcrumbLevel: h.breadcrumbLevel,
+	}
 	return h, nil
 }
 
@@ -38,12 +47,36 @@ func (h *Handler) SetDepth(depth int) {
 	h.depth = depth
 }
 
+// SetBreadcrumbLevel enables recording of log entries below HandlerSeverity
+// and at or above level as notifier breadcrumbs instead of dropping them.
+func (h *Handler) SetBreadcrumbLevel(level log.Level) {
+	h.breadcrumbs = true
+	h.breadcrumbLevel = level
+}
+
 // HandleLog method is used for sending notices to airbrake.
 func (h *Handler) HandleLog(e *log.Entry) error {
+	if e.Level < h.HandlerSeverity {
+		h.addBreadcrumb(e)
+		return nil
+	}
 	h.notifyAirbrake(e.Level, e.Message, e.Fields)
 	return nil
 }
 
+func (h *Handler) addBreadcrumb(e *log.Entry) {
+	if !h.breadcrumbs || e.Level < h.breadcrumbLevel {
+		return
+	}
+	h.Gobrake.Breadcrumbs.Add(gobrake.Breadcrumb{
+		Timestamp: e.Timestamp,
+		Category:  gobrake.BreadcrumbLog,
+		Message:   e.Message,
+		Level:     e.Level.String(),
+		Data:      asParams(e.Fields),
+	})
+}
+
 func (h *Handler) notifyAirbrake(level log.Level, msg string, params log.Fields) {
 	if level < h.HandlerSeverity {
 		return
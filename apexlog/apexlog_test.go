@@ -0,0 +1,43 @@
+package apexlog
+
+import (
+	"testing"
+
+	"github.com/airbrake/gobrake/v5"
+	"github.com/airbrake/gobrake/v5/internal/testutil"
+	"github.com/apex/log"
+)
+
+func TestHandlerBreadcrumbs(t *testing.T) {
+	notifier := testutil.NewNotifier(t)
+	h := New(notifier, log.ErrorLevel)
+	h.SetBreadcrumbLevel(log.InfoLevel)
+
+	logger := &log.Logger{Handler: h, Level: log.DebugLevel}
+	logger.Debug("dropped")
+	logger.WithField("foo", "bar").Info("hello")
+
+	bcs := notifier.Breadcrumbs.All()
+	if len(bcs) != 1 {
+		t.Fatalf("got %d breadcrumbs, wanted 1", len(bcs))
+	}
+	bc := bcs[0]
+	if bc.Message != "hello" || bc.Level != "info" || bc.Category != gobrake.BreadcrumbLog {
+		t.Fatalf("got %+v", bc)
+	}
+	if len(bc.Data) != 1 || bc.Data["foo"] != "bar" {
+		t.Fatalf("got data %v, wanted map[foo:bar]", bc.Data)
+	}
+}
+
+func TestHandlerBreadcrumbsDisabled(t *testing.T) {
+	notifier := testutil.NewNotifier(t)
+	h := New(notifier, log.ErrorLevel)
+
+	logger := &log.Logger{Handler: h, Level: log.DebugLevel}
+	logger.Info("hello")
+
+	if bcs := notifier.Breadcrumbs.All(); len(bcs) != 0 {
+		t.Fatalf("got %d breadcrumbs, wanted 0", len(bcs))
+	}
+}
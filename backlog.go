@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"sync/atomic"
+	"sync"
 	"time"
 )
 
@@ -14,330 +14,195 @@ const (
 	flushBacklogPeriod = 60 * time.Second
 )
 
+// noticeBacklog keeps notices that were not delivered because of a
+// temporary error and sends them again after the backlog period.
 type noticeBacklog struct {
-	opt       *NotifierOptions
-	notices   []Notice
-	maxLength int
-}
-
-type apmBacklog struct {
-	opt             *NotifierOptions
-	routeStats      []routesOut
-	routeBreakdowns []breakdownsOut
-	queries         []queriesOut
-	queues          []queuesOut
-}
-
-var (
-	nb              *noticeBacklog
-	ab              *apmBacklog
-	apmBacklogCount int32
-)
+	dest *destination
 
-// Purge reset the backlog APM count to 0.
-func purge() {
-	atomic.StoreInt32(&apmBacklogCount, 0)
+	mu         sync.Mutex
+	notices    []Notice
+	flushTimer *time.Timer
+	closed     bool
 }
 
-// inc increase the backlog APM count by 1.
-func inc() {
-	atomic.StoreInt32(&apmBacklogCount, getCount()+1)
-}
+// apmBacklog keeps APM stats that were not delivered because of a
+// temporary error and sends them again after the backlog period.
+type apmBacklog struct {
+	dest *destination
 
-// getCount returns the backlog APM count.
-func getCount() int32 {
-	return atomic.LoadInt32(&apmBacklogCount)
+	mu         sync.Mutex
+	stats      []apmBacklogStat
+	flushTimer *time.Timer
+	closed     bool
 }
 
-// newBacklog creates a new backlog for notices and APM stats.
-func newBacklog(opt *NotifierOptions) {
-	nb = &noticeBacklog{
-		maxLength: backlogSize,
-		opt:       opt,
-	}
-	ab = &apmBacklog{
-		opt: opt,
-	}
+type apmBacklogStat struct {
+	path string
+	out  interface{}
 }
 
-// setNoticeBacklog sets new backlog notice.
-func setNoticeBacklog(notice *Notice) {
-	if nb.opt.DisableBacklog {
+// add adds the notice to the backlog.
+func (nb *noticeBacklog) add(notice *Notice) {
+	if nb.dest.options().DisableBacklog {
 		return
 	}
-	if len(nb.notices) < nb.maxLength {
-		nb.notices = append(nb.notices, *notice)
-	}
-	for {
-		<-time.After(flushBacklogPeriod)
-		nb.flushNoticeBacklog()
-	}
+	nb.requeue([]Notice{*notice})
 }
 
-// flushNoticeBacklog sends the backlog notice after the backlog period is over.
-func (nb *noticeBacklog) flushNoticeBacklog() {
-	buf := buffers.Get().(*bytes.Buffer)
-
-	for _, notice := range nb.notices {
-		err := json.NewEncoder(buf).Encode(notice)
-		if err != nil {
-			logger.Printf("Backlog notice failed = %s", err)
-			continue
-		}
-
-		req, err := http.NewRequest(
-			http.MethodPost,
-			fmt.Sprintf("%s/api/v3/projects/%d/notices",
-				nb.opt.Host, nb.opt.ProjectId),
-			buf,
-		)
-		if err != nil {
-			logger.Printf("Backlog notice failed = %s", err)
-			continue
-		}
-
-		setRequestHeaders(req, nb.opt.ProjectKey)
-
-		resp, err := nb.opt.HTTPClient.Do(req)
-		if err != nil {
-			logger.Printf("Backlog notice failed = %s", err)
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode > 400 {
-			logger.Printf("Backlog notice failed = %q", resp.Status)
-		}
-
-		buf.Reset()
-	}
-
-	nb.notices = nil
-}
+// requeue adds notices to the backlog until it is full and schedules a
+// flush.
+func (nb *noticeBacklog) requeue(notices []Notice) {
+	nb.mu.Lock()
+	defer nb.mu.Unlock()
 
-// setRouteStatBacklog sets new backlog route stat.
-func setRouteStatBacklog(routeStat routesOut) {
-	if ab.opt.DisableBacklog {
+	if nb.closed {
 		return
 	}
-	if getCount() < backlogSize {
-		ab.routeStats = append(ab.routeStats, routeStat)
-		inc()
+	if n := backlogSize - len(nb.notices); len(notices) > n {
+		notices = notices[:n]
 	}
-	for {
-		<-time.After(flushBacklogPeriod)
-		ab.flushRouteStatBacklog()
+	nb.notices = append(nb.notices, notices...)
+	if len(nb.notices) > 0 && nb.flushTimer == nil {
+		nb.flushTimer = time.AfterFunc(flushBacklogPeriod, nb.flush)
 	}
 }
 
-// flushRouteStatBacklog sends the backlog route stats after the backlog period is over.
-func (ab *apmBacklog) flushRouteStatBacklog() {
-	buf := buffers.Get().(*bytes.Buffer)
-
-	for _, routeStat := range ab.routeStats {
-		err := json.NewEncoder(buf).Encode(routeStat)
-		if err != nil {
-			logger.Printf("Backlog route stat failed = %s", err)
-			continue
-		}
-
-		req, err := http.NewRequest(
-			http.MethodPut,
-			fmt.Sprintf("%s/api/v5/projects/%d/routes-stats",
-				ab.opt.APMHost, ab.opt.ProjectId),
-			buf,
-		)
-		if err != nil {
-			logger.Printf("Backlog route stat failed = %s", err)
-			continue
-		}
-
-		setRequestHeaders(req, ab.opt.ProjectKey)
-
-		resp, err := ab.opt.HTTPClient.Do(req)
+// flush sends the backlog notices after the backlog period is over.
+// Notices that fail with a temporary error are added back.
+func (nb *noticeBacklog) flush() {
+	nb.mu.Lock()
+	notices := nb.notices
+	nb.notices = nil
+	nb.flushTimer = nil
+	nb.mu.Unlock()
+
+	opt := nb.dest.options()
+	url := fmt.Sprintf("%s/api/v3/projects/%d/notices", opt.Host, opt.ProjectId)
+	var failed []Notice
+	for i := range notices {
+		retry, err := sendBacklog(opt, http.MethodPost, url, &notices[i])
 		if err != nil {
-			logger.Printf("Backlog route stat failed = %s", err)
-			continue
+			opt.log().Warn("backlog notice failed", "error", err)
+			if retry {
+				failed = append(failed, notices[i])
+			}
 		}
-		defer resp.Body.Close()
+	}
+	nb.requeue(failed)
+}
 
-		if resp.StatusCode > 400 {
-			logger.Printf("Backlog route stat failed = %q", resp.Status)
-		}
+// close stops the flush timer. Notices in the backlog are dropped.
+func (nb *noticeBacklog) close() {
+	nb.mu.Lock()
+	defer nb.mu.Unlock()
 
-		buf.Reset()
+	nb.closed = true
+	nb.notices = nil
+	if nb.flushTimer != nil {
+		nb.flushTimer.Stop()
+		nb.flushTimer = nil
 	}
-
-	ab.routeStats = nil
-	purge()
 }
 
-// setRouteBreakdownBacklog sets new backlog route breakdown.
-func setRouteBreakdownBacklog(routeBreakdown breakdownsOut) {
-	if ab.opt.DisableBacklog {
+// add adds APM stats sent to path to the backlog.
+func (ab *apmBacklog) add(path string, out interface{}) {
+	if ab.dest.options().DisableBacklog {
 		return
 	}
-	if getCount() < backlogSize {
-		ab.routeBreakdowns = append(ab.routeBreakdowns, routeBreakdown)
-		inc()
-	}
-	for {
-		<-time.After(flushBacklogPeriod)
-		ab.flushRouteBreakdownBacklog()
-	}
+	ab.requeue([]apmBacklogStat{{path: path, out: out}})
 }
 
-// flushBacklogRouteBreakdown sends the backlog route breakdowns after the backlog period is over.
-func (ab *apmBacklog) flushRouteBreakdownBacklog() {
-	buf := buffers.Get().(*bytes.Buffer)
-
-	for _, routeBreakdown := range ab.routeBreakdowns {
-		err := json.NewEncoder(buf).Encode(routeBreakdown)
-		if err != nil {
-			logger.Printf("Backlog route stat failed = %s", err)
-			continue
-		}
-
-		req, err := http.NewRequest(
-			http.MethodPut,
-			fmt.Sprintf("%s/api/v5/projects/%d/routes-breakdowns",
-				ab.opt.APMHost, ab.opt.ProjectId),
-			buf,
-		)
-		if err != nil {
-			logger.Printf("Backlog route stat failed = %s", err)
-			continue
-		}
-
-		setRequestHeaders(req, ab.opt.ProjectKey)
-
-		resp, err := ab.opt.HTTPClient.Do(req)
-		if err != nil {
-			logger.Printf("Backlog route stat failed = %s", err)
-			continue
-		}
-		defer resp.Body.Close()
+// requeue adds stats to the backlog until it is full and schedules a flush.
+func (ab *apmBacklog) requeue(stats []apmBacklogStat) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
 
-		if resp.StatusCode > 400 {
-			logger.Printf("Backlog route stat failed = %q", resp.Status)
-		}
-
-		buf.Reset()
-	}
-
-	ab.routeBreakdowns = nil
-}
-
-// setQueryBacklog sets new backlog query.
-func setQueryBacklog(query queriesOut) {
-	if ab.opt.DisableBacklog {
+	if ab.closed {
 		return
 	}
-	if getCount() < backlogSize {
-		ab.queries = append(ab.queries, query)
-		inc()
+	if n := backlogSize - len(ab.stats); len(stats) > n {
+		stats = stats[:n]
 	}
-	for {
-		<-time.After(flushBacklogPeriod)
-		ab.flushQueryBacklog()
+	ab.stats = append(ab.stats, stats...)
+	if len(ab.stats) > 0 && ab.flushTimer == nil {
+		ab.flushTimer = time.AfterFunc(flushBacklogPeriod, ab.flush)
 	}
 }
 
-// flushQueryBacklog sends the backlog query after the backlog period is over.
-func (ab *apmBacklog) flushQueryBacklog() {
-	buf := buffers.Get().(*bytes.Buffer)
-
-	for _, query := range ab.queries {
-		err := json.NewEncoder(buf).Encode(query)
-		if err != nil {
-			logger.Printf("Backlog query failed = %s", err)
-			continue
-		}
-
-		req, err := http.NewRequest(
-			http.MethodPut,
-			fmt.Sprintf("%s/api/v5/projects/%d/queries-stats",
-				ab.opt.APMHost, ab.opt.ProjectId),
-			buf,
-		)
-		if err != nil {
-			logger.Printf("Backlog query failed = %s", err)
-			continue
-		}
-
-		setRequestHeaders(req, ab.opt.ProjectKey)
+// flush sends the backlog APM stats after the backlog period is over.
+// Stats that fail with a temporary error are added back.
+func (ab *apmBacklog) flush() {
+	ab.mu.Lock()
+	stats := ab.stats
+	ab.stats = nil
+	ab.flushTimer = nil
+	ab.mu.Unlock()
 
-		resp, err := ab.opt.HTTPClient.Do(req)
+	opt := ab.dest.options()
+	var failed []apmBacklogStat
+	for _, s := range stats {
+		url := fmt.Sprintf("%s/api/v5/projects/%d/%s", opt.APMHost, opt.ProjectId, s.path)
+		retry, err := sendBacklog(opt, http.MethodPut, url, s.out)
 		if err != nil {
-			logger.Printf("Backlog query failed = %s", err)
-			continue
+			opt.log().Warn("backlog APM stats failed", "path", s.path, "error", err)
+			if retry {
+				failed = append(failed, s)
+			}
 		}
-		defer resp.Body.Close()
+	}
+	ab.requeue(failed)
+}
 
-		if resp.StatusCode > 400 {
-			logger.Printf("Backlog query failed = %q", resp.Status)
-		}
+// close stops the flush timer. Stats in the backlog are dropped.
+func (ab *apmBacklog) close() {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
 
-		buf.Reset()
+	ab.closed = true
+	ab.stats = nil
+	if ab.flushTimer != nil {
+		ab.flushTimer.Stop()
+		ab.flushTimer = nil
 	}
-
-	ab.queries = nil
 }
 
-// setQueueBacklog sets new queue backlog.
-func setQueueBacklog(queue queuesOut) {
-	if ab.opt.DisableBacklog {
-		return
-	}
-	if getCount() < backlogSize {
-		ab.queues = append(ab.queues, queue)
-		inc()
-	}
-	for {
-		<-time.After(flushBacklogPeriod)
-		ab.flushQueueBacklog()
+// isBacklogStatus reports whether a request that failed with the status
+// code is added to the backlog.
+func isBacklogStatus(code int) bool {
+	switch code {
+	case 404, 408, 409, 410, 500, 502, 504:
+		return true
 	}
+	return false
 }
 
-// flushQueueBacklog sends the queue backlog after the backlog period is over.
-func (ab *apmBacklog) flushQueueBacklog() {
+// sendBacklog sends v to url. retry is true when the request failed with a
+// temporary error and should be sent again later.
+func sendBacklog(opt *NotifierOptions, method, url string, v interface{}) (retry bool, err error) {
 	buf := buffers.Get().(*bytes.Buffer)
+	defer buffers.Put(buf)
 
-	for _, queue := range ab.queues {
-		err := json.NewEncoder(buf).Encode(queue)
-		if err != nil {
-			logger.Printf("Backlog queue failed = %s", err)
-			continue
-		}
-
-		req, err := http.NewRequest(
-			http.MethodPut,
-			fmt.Sprintf("%s/api/v5/projects/%d/queues-stats",
-				ab.opt.APMHost, ab.opt.ProjectId),
-			buf,
-		)
-		if err != nil {
-			logger.Printf("Backlog queue failed = %s", err)
-			continue
-		}
-
-		setRequestHeaders(req, ab.opt.ProjectKey)
-
-		resp, err := ab.opt.HTTPClient.Do(req)
-		if err != nil {
-			logger.Printf("Backlog queue failed = %s", err)
-			continue
-		}
-		defer resp.Body.Close()
+	buf.Reset()
+	err = json.NewEncoder(buf).Encode(v)
+	if err != nil {
+		return false, err
+	}
 
-		if resp.StatusCode > 400 {
-			logger.Printf("Backlog queue failed = %q", resp.Status)
-		}
+	req, err := newRequest(opt, method, url, buf)
+	if err != nil {
+		return false, err
+	}
 
-		buf.Reset()
+	resp, err := opt.HTTPClient.Do(req)
+	if err != nil {
+		return true, err
 	}
+	defer resp.Body.Close()
 
-	ab.queues = nil
+	if resp.StatusCode > 400 {
+		return isBacklogStatus(resp.StatusCode), fmt.Errorf("%q", resp.Status)
+	}
+	return false, nil
 }
 
 func setRequestHeaders(req *http.Request, projectKey string) {
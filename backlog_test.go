@@ -0,0 +1,115 @@
+package gobrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("backlog", func() {
+	var status int32
+	var requests int32
+	var dest *destination
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&status, http.StatusInternalServerError)
+		atomic.StoreInt32(&requests, 0)
+
+		server := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, req *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(int(atomic.LoadInt32(&status)))
+			},
+		))
+		DeferCleanup(server.Close)
+
+		opt := &NotifierOptions{
+			ProjectId:  1,
+			ProjectKey: "key",
+			Host:       server.URL,
+		}
+		opt.init()
+		dest = newDestination(newSharedOptions(opt), nil, new(sync.WaitGroup))
+	})
+
+	AfterEach(func() {
+		dest.nb.close()
+		dest.ab.close()
+	})
+
+	backlogNotices := func() []Notice {
+		dest.nb.mu.Lock()
+		defer dest.nb.mu.Unlock()
+		return dest.nb.notices
+	}
+
+	It("adds back notices that fail with a temporary error", func() {
+		dest.nb.add(NewNotice(errors.New("hello"), nil, 0))
+
+		dest.nb.flush()
+
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+		Expect(backlogNotices()).To(HaveLen(1))
+		Expect(dest.nb.flushTimer).NotTo(BeNil())
+	})
+
+	It("drops notices that fail with a permanent error", func() {
+		atomic.StoreInt32(&status, http.StatusUnauthorized)
+		dest.nb.add(NewNotice(errors.New("hello"), nil, 0))
+
+		dest.nb.flush()
+
+		Expect(backlogNotices()).To(BeEmpty())
+		Expect(dest.nb.flushTimer).To(BeNil())
+	})
+
+	It("caps notices added back at the backlog size", func() {
+		for i := 0; i < backlogSize; i++ {
+			dest.nb.add(NewNotice(errors.New("hello"), nil, 0))
+		}
+
+		dest.nb.mu.Lock()
+		notices := dest.nb.notices
+		dest.nb.notices = nil
+		dest.nb.mu.Unlock()
+
+		dest.nb.add(NewNotice(errors.New("new"), nil, 0))
+		dest.nb.requeue(notices)
+
+		Expect(backlogNotices()).To(HaveLen(backlogSize))
+		Expect(backlogNotices()[0].Errors[0].Message).To(Equal("new"))
+	})
+
+	It("adds back APM stats that fail with a temporary error", func() {
+		dest.ab.add("routes-stats", map[string]int{"count": 1})
+
+		dest.ab.flush()
+
+		dest.ab.mu.Lock()
+		defer dest.ab.mu.Unlock()
+		Expect(dest.ab.stats).To(HaveLen(1))
+		Expect(dest.ab.stats[0].path).To(Equal("routes-stats"))
+	})
+
+	It("stops timers on close", func() {
+		dest.nb.add(NewNotice(errors.New("hello"), nil, 0))
+		dest.ab.add("routes-stats", map[string]int{"count": 1})
+		Expect(dest.nb.flushTimer).NotTo(BeNil())
+		Expect(dest.ab.flushTimer).NotTo(BeNil())
+
+		dest.nb.close()
+		dest.ab.close()
+
+		Expect(dest.nb.flushTimer).To(BeNil())
+		Expect(dest.ab.flushTimer).To(BeNil())
+
+		dest.nb.add(NewNotice(errors.New("hello"), nil, 0))
+		Expect(backlogNotices()).To(BeEmpty())
+		Expect(dest.nb.flushTimer).To(BeNil())
+	})
+})
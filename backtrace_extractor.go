@@ -0,0 +1,154 @@
+package gobrake
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// BacktraceExtractor returns program counters of the stack trace stored in
+// err. It returns false when err does not carry a stack trace.
+type BacktraceExtractor func(err error) ([]uintptr, bool)
+
+var (
+	backtraceExtractorsMu sync.RWMutex
+	// userBacktraceExtractors are tried before builtinBacktraceExtractors.
+	userBacktraceExtractors    []BacktraceExtractor
+	builtinBacktraceExtractors = []BacktraceExtractor{
+		pkgErrorsExtractor,
+		callersExtractor,
+		stackFramesExtractor,
+		reflectStackTraceExtractor,
+	}
+)
+
+// RegisterBacktraceExtractor adds fn to the list of extractors used to get
+// backtraces from errors. Built-in extractors support github.com/pkg/errors,
+// github.com/go-errors/errors, github.com/cockroachdb/errors,
+// emperror.dev/errors and any error with a Callers() []uintptr method.
+// Registered extractors are tried in the order they were registered and
+// before the built-in ones, so they can override how a supported library
+// is handled.
+func RegisterBacktraceExtractor(fn BacktraceExtractor) {
+	backtraceExtractorsMu.Lock()
+	userBacktraceExtractors = append(userBacktraceExtractors, fn)
+	backtraceExtractorsMu.Unlock()
+}
+
+// extractStack returns the stack trace of the first error in the err chain
+// that carries one.
+func extractStack(err error) ([]uintptr, bool) {
+	backtraceExtractorsMu.RLock()
+	extractors := userBacktraceExtractors
+	backtraceExtractorsMu.RUnlock()
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		for _, extract := range extractors {
+			if pcs, ok := extract(err); ok && len(pcs) > 0 {
+				return pcs, true
+			}
+		}
+		for _, extract := range builtinBacktraceExtractors {
+			if pcs, ok := extract(err); ok && len(pcs) > 0 {
+				return pcs, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// pkgErrorsExtractor supports github.com/pkg/errors and
+// github.com/cockroachdb/errors which shares its StackTrace type.
+func pkgErrorsExtractor(err error) ([]uintptr, bool) {
+	st, ok := err.(stackTracer)
+	if !ok {
+		return nil, false
+	}
+	return stackTracerPCs(st), true
+}
+
+func stackTracerPCs(st stackTracer) []uintptr {
+	stackTrace := st.StackTrace()
+	pcs := make([]uintptr, len(stackTrace))
+	for i, f := range stackTrace {
+		pcs[i] = uintptr(f)
+	}
+	return pcs
+}
+
+type callerser interface {
+	Callers() []uintptr
+}
+
+// callersExtractor supports github.com/go-errors/errors and errors that
+// expose raw program counters.
+func callersExtractor(err error) ([]uintptr, bool) {
+	c, ok := err.(callerser)
+	if !ok {
+		return nil, false
+	}
+	return c.Callers(), true
+}
+
+// stackFramesExtractor supports errors with a StackFrames method returning
+// a slice of structs with a ProgramCounter field, e.g. go-errors.
+func stackFramesExtractor(err error) ([]uintptr, bool) {
+	frames, ok := callSliceMethod(err, "StackFrames")
+	if !ok {
+		return nil, false
+	}
+
+	pcs := make([]uintptr, 0, frames.Len())
+	for i := 0; i < frames.Len(); i++ {
+		frame := reflect.Indirect(frames.Index(i))
+		if frame.Kind() != reflect.Struct {
+			return nil, false
+		}
+		pc := frame.FieldByName("ProgramCounter")
+		if !pc.IsValid() || pc.Kind() != reflect.Uintptr {
+			return nil, false
+		}
+		pcs = append(pcs, uintptr(pc.Uint()))
+	}
+	return pcs, true
+}
+
+// reflectStackTraceExtractor supports errors with a StackTrace method that
+// returns frames of their own uintptr based type, e.g. emperror.
+func reflectStackTraceExtractor(err error) ([]uintptr, bool) {
+	frames, ok := callSliceMethod(err, "StackTrace")
+	if !ok || frames.Type().Elem().Kind() != reflect.Uintptr {
+		return nil, false
+	}
+
+	pcs := make([]uintptr, frames.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs, true
+}
+
+// callSliceMethod calls the method of v that takes no arguments and returns
+// a slice. It returns false when v is a nil pointer or the method panics.
+func callSliceMethod(v interface{}, name string) (out reflect.Value, ok bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return reflect.Value{}, false
+	}
+	m := rv.MethodByName(name)
+	if !m.IsValid() {
+		return reflect.Value{}, false
+	}
+	typ := m.Type()
+	if typ.NumIn() != 0 || typ.NumOut() != 1 || typ.Out(0).Kind() != reflect.Slice {
+		return reflect.Value{}, false
+	}
+
+	defer func() {
+		if recover() != nil {
+			out, ok = reflect.Value{}, false
+		}
+	}()
+	return m.Call(nil)[0], true
+}
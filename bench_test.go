@@ -64,3 +64,102 @@ func BenchmarkRoutesNotify(b *testing.B) {
 		}
 	})
 }
+
+func newBenchmarkNotifier(b *testing.B) *gobrake.Notifier {
+	// Serves APM stats and remote config.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	b.Cleanup(server.Close)
+
+	notifier := gobrake.NewNotifierWithOptions(&gobrake.NotifierOptions{
+		ProjectId:        1,
+		ProjectKey:       "key",
+		Host:             server.URL,
+		RemoteConfigHost: server.URL,
+	})
+	b.Cleanup(func() {
+		_ = notifier.Close()
+	})
+	return notifier
+}
+
+var benchmarkRoutes = []string{
+	"/api/v4/groups",
+	"/api/v4/groups/:id",
+	"/api/v4/projects",
+	"/api/v4/projects/:id",
+	"/api/v4/projects/:id/notices",
+	"/api/v4/projects/:id/routes",
+	"/api/v4/users",
+	"/api/v4/users/:id",
+}
+
+// BenchmarkRouteMetric measures the middleware hot path: starting a route
+// metric and notifying about it.
+func BenchmarkRouteMetric(b *testing.B) {
+	notifier := newBenchmarkNotifier(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			c, metric := gobrake.NewRouteMetric(context.Background(), "GET", benchmarkRoutes[i%len(benchmarkRoutes)])
+			metric.StatusCode = http.StatusOK
+			err := notifier.Routes.Notify(c, metric)
+			if err != nil {
+				b.Fatal(err)
+			}
+			metric.Release()
+			i++
+		}
+	})
+}
+
+// BenchmarkRouteMetricContention measures lock contention with many
+// goroutines notifying about the same routes, e.g. a server handling 50k
+// requests per second. Compare runs with different -cpu values.
+func BenchmarkRouteMetricContention(b *testing.B) {
+	notifier := newBenchmarkNotifier(b)
+
+	b.ReportAllocs()
+	b.SetParallelism(64)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			c, metric := gobrake.NewRouteMetric(context.Background(), "GET", benchmarkRoutes[i%len(benchmarkRoutes)])
+			metric.StatusCode = http.StatusOK
+			err := notifier.Routes.Notify(c, metric)
+			if err != nil {
+				b.Fatal(err)
+			}
+			metric.Release()
+			i++
+		}
+	})
+}
+
+// BenchmarkRouteMetricSpans measures a request with a nested span.
+func BenchmarkRouteMetricSpans(b *testing.B) {
+	notifier := newBenchmarkNotifier(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c, metric := gobrake.NewRouteMetric(context.Background(), "GET", benchmarkRoutes[i%len(benchmarkRoutes)])
+		_, span := gobrake.ContextMetric(c).Start(c, "sql")
+		span.Finish()
+		metric.StatusCode = http.StatusOK
+		err := notifier.Routes.Notify(c, metric)
+		if err != nil {
+			b.Fatal(err)
+		}
+		metric.Release()
+	}
+}
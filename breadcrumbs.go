@@ -0,0 +1,182 @@
+package gobrake
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+const breadcrumbsCtxKey ctxKey = "ab_breadcrumbs"
+
+const defaultMaxBreadcrumbs = 25
+
+// Breadcrumb categories used by gobrake.
+const (
+	BreadcrumbLog   = "log"
+	BreadcrumbHTTP  = "http"
+	BreadcrumbQuery = "query"
+)
+
+// Breadcrumb is an event that happened before an error, e.g. a log line,
+// an HTTP call or an SQL query.
+type Breadcrumb struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Category  string                 `json:"category"`
+	Message   string                 `json:"message,omitempty"`
+	Level     string                 `json:"level,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Breadcrumbs is a ring buffer that keeps the most recent breadcrumbs.
+// It is safe for concurrent use.
+type Breadcrumbs struct {
+	mu   sync.Mutex
+	buf  []Breadcrumb
+	next int
+	full bool
+}
+
+// NewBreadcrumbs returns Breadcrumbs that keeps the last size breadcrumbs.
+func NewBreadcrumbs(size int) *Breadcrumbs {
+	if size <= 0 {
+		size = defaultMaxBreadcrumbs
+	}
+	return &Breadcrumbs{
+		buf: make([]Breadcrumb, size),
+	}
+}
+
+// Add records the breadcrumb, evicting the oldest one when the buffer is full.
+func (b *Breadcrumbs) Add(bc Breadcrumb) {
+	if b == nil {
+		return
+	}
+	if bc.Timestamp.IsZero() {
+		bc.Timestamp = clock.Now()
+	}
+
+	b.mu.Lock()
+	b.buf[b.next] = bc
+	b.next++
+	if b.next == len(b.buf) {
+		b.next = 0
+		b.full = true
+	}
+	b.mu.Unlock()
+}
+
+// All returns recorded breadcrumbs from the oldest to the newest.
+func (b *Breadcrumbs) All() []Breadcrumb {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return append([]Breadcrumb(nil), b.buf[:b.next]...)
+	}
+	out := make([]Breadcrumb, 0, len(b.buf))
+	out = append(out, b.buf[b.next:]...)
+	out = append(out, b.buf[:b.next]...)
+	return out
+}
+
+// ContextWithBreadcrumbs returns a copy of c that carries b. HTTP calls made
+// with the returned context are recorded in b when the response arrives or
+// the call fails, with the duration and the error.
+func ContextWithBreadcrumbs(c context.Context, b *Breadcrumbs) context.Context {
+	c = context.WithValue(c, breadcrumbsCtxKey, b)
+	t := &httpBreadcrumbTrace{b: b}
+	return httptrace.WithClientTrace(c, &httptrace.ClientTrace{
+		GetConn: t.getConn,
+		ConnectDone: func(network, addr string, err error) {
+			if err != nil {
+				t.done(err)
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				t.done(err)
+			}
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err != nil {
+				t.done(info.Err)
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.done(nil)
+		},
+	})
+}
+
+// httpBreadcrumbTrace records an HTTP call made with a context as a single
+// breadcrumb.
+type httpBreadcrumbTrace struct {
+	b *Breadcrumbs
+
+	mu      sync.Mutex
+	host    string
+	start   time.Time
+	started bool
+}
+
+func (t *httpBreadcrumbTrace) getConn(hostPort string) {
+	t.mu.Lock()
+	t.host = hostPort
+	t.start = clock.Now()
+	t.started = true
+	t.mu.Unlock()
+}
+
+func (t *httpBreadcrumbTrace) done(err error) {
+	t.mu.Lock()
+	if !t.started {
+		t.mu.Unlock()
+		return
+	}
+	t.started = false
+	host, start := t.host, t.start
+	t.mu.Unlock()
+
+	data := map[string]interface{}{
+		"durationMs": durInMs(clock.Since(start)),
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	t.b.Add(Breadcrumb{
+		Timestamp: start,
+		Category:  BreadcrumbHTTP,
+		Message:   host,
+		Data:      data,
+	})
+}
+
+// ContextBreadcrumbs returns Breadcrumbs stored in c or nil.
+func ContextBreadcrumbs(c context.Context) *Breadcrumbs {
+	if c == nil {
+		return nil
+	}
+	b, _ := c.Value(breadcrumbsCtxKey).(*Breadcrumbs)
+	return b
+}
+
+func newBreadcrumbsFilter(notifier *Notifier) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		if _, ok := notice.Context["breadcrumbs"]; ok {
+			return notice
+		}
+		if notice.Context == nil {
+			notice.Context = make(map[string]interface{})
+		}
+		if bcs := notifier.Breadcrumbs.All(); len(bcs) > 0 {
+			notice.Context["breadcrumbs"] = bcs
+		}
+		return notice
+	}
+}
@@ -0,0 +1,83 @@
+package gobrake_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/airbrake/gobrake/v5"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Breadcrumbs", func() {
+	messages := func(bcs []gobrake.Breadcrumb) []string {
+		var ss []string
+		for _, bc := range bcs {
+			ss = append(ss, bc.Message)
+		}
+		return ss
+	}
+
+	It("keeps the most recent breadcrumbs in order", func() {
+		b := gobrake.NewBreadcrumbs(3)
+		Expect(b.All()).To(BeEmpty())
+
+		for _, msg := range []string{"1", "2", "3", "4", "5"} {
+			b.Add(gobrake.Breadcrumb{Category: gobrake.BreadcrumbLog, Message: msg})
+		}
+
+		bcs := b.All()
+		Expect(messages(bcs)).To(Equal([]string{"3", "4", "5"}))
+		Expect(bcs[0].Timestamp).NotTo(BeZero())
+	})
+
+	It("supports nil breadcrumbs", func() {
+		var b *gobrake.Breadcrumbs
+		b.Add(gobrake.Breadcrumb{Message: "1"})
+		Expect(b.All()).To(BeNil())
+	})
+
+	It("records HTTP calls made with context", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		b := gobrake.NewBreadcrumbs(3)
+		c := gobrake.ContextWithBreadcrumbs(context.Background(), b)
+		Expect(gobrake.ContextBreadcrumbs(c)).To(Equal(b))
+
+		req, err := http.NewRequestWithContext(c, "GET", server.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+
+		bcs := b.All()
+		Expect(bcs).To(HaveLen(1))
+		Expect(bcs[0].Category).To(Equal(gobrake.BreadcrumbHTTP))
+		Expect(bcs[0].Message).To(Equal(strings.TrimPrefix(server.URL, "http://")))
+		Expect(bcs[0].Data).To(HaveKey("durationMs"))
+		Expect(bcs[0].Data).NotTo(HaveKey("error"))
+	})
+
+	It("records failed HTTP calls with the error", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+
+		b := gobrake.NewBreadcrumbs(3)
+		c := gobrake.ContextWithBreadcrumbs(context.Background(), b)
+
+		req, err := http.NewRequestWithContext(c, "GET", server.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = http.DefaultClient.Do(req)
+		Expect(err).To(HaveOccurred())
+
+		bcs := b.All()
+		Expect(bcs).To(HaveLen(1))
+		Expect(bcs[0].Message).To(Equal(strings.TrimPrefix(server.URL, "http://")))
+		Expect(bcs[0].Data).To(HaveKey("durationMs"))
+		Expect(bcs[0].Data["error"]).To(ContainSubstring("connection refused"))
+	})
+})
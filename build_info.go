@@ -0,0 +1,102 @@
+package gobrake
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// buildInfo describes the running binary as stamped by the Go toolchain.
+// It works without the source tree and git, e.g. in scratch Docker images.
+type buildInfo struct {
+	Path      string
+	Version   string
+	GoVersion string
+	Revision  string
+	Time      string
+	Modified  bool
+	Deps      map[string]string
+}
+
+var (
+	buildInfoOnce sync.Once
+	_buildInfo    *buildInfo
+)
+
+// getBuildInfo returns the build info of the running binary or nil when it
+// was built without module support.
+func getBuildInfo() *buildInfo {
+	buildInfoOnce.Do(func() {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			_buildInfo = parseBuildInfo(bi)
+		}
+	})
+	return _buildInfo
+}
+
+func parseModules(bi *debug.BuildInfo) *buildInfo {
+	info := &buildInfo{
+		Path:      bi.Main.Path,
+		Version:   bi.Main.Version,
+		GoVersion: runtime.Version(),
+		Deps:      make(map[string]string, len(bi.Deps)),
+	}
+	for _, dep := range bi.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		info.Deps[dep.Path] = dep.Version
+	}
+	return info
+}
+
+// mainVersion returns the main module version unless it is the placeholder
+// used for builds from a source tree.
+func (info *buildInfo) mainVersion() string {
+	if info.Version == "(devel)" {
+		return ""
+	}
+	return info.Version
+}
+
+// context returns the build info attached to notices. Dependencies are only
+// attached when NotifierOptions.EnableBuildDependencies is set, because the
+// list can be long.
+func (info *buildInfo) context() map[string]interface{} {
+	m := map[string]interface{}{
+		"goVersion": info.GoVersion,
+	}
+	if info.Path != "" {
+		m["path"] = info.Path
+	}
+	if v := info.mainVersion(); v != "" {
+		m["version"] = v
+	}
+	if info.Revision != "" {
+		m["revision"] = info.Revision
+		m["modified"] = info.Modified
+	}
+	if info.Time != "" {
+		m["time"] = info.Time
+	}
+	return m
+}
+
+// newBuildDependenciesFilter adds the module dependencies of the binary to
+// the build info of notices when EnableBuildDependencies is set.
+func newBuildDependenciesFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		if !opts.Load().EnableBuildDependencies {
+			return notice
+		}
+		info := getBuildInfo()
+		if info == nil {
+			return notice
+		}
+		// The build info map is shared by all notices.
+		m := info.context()
+		m["dependencies"] = info.Deps
+		notice.Context["buildInfo"] = m
+		return notice
+	}
+}
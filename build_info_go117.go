@@ -0,0 +1,13 @@
+//go:build !go1.18
+// +build !go1.18
+
+package gobrake
+
+import (
+	"runtime/debug"
+)
+
+// VCS settings are only available since Go 1.18.
+func parseBuildInfo(bi *debug.BuildInfo) *buildInfo {
+	return parseModules(bi)
+}
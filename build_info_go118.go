@@ -0,0 +1,27 @@
+//go:build go1.18
+// +build go1.18
+
+package gobrake
+
+import (
+	"runtime/debug"
+	"strconv"
+)
+
+func parseBuildInfo(bi *debug.BuildInfo) *buildInfo {
+	info := parseModules(bi)
+	if bi.GoVersion != "" {
+		info.GoVersion = bi.GoVersion
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.Time = s.Value
+		case "vcs.modified":
+			info.Modified, _ = strconv.ParseBool(s.Value)
+		}
+	}
+	return info
+}
@@ -0,0 +1,106 @@
+//go:build go1.18
+// +build go1.18
+
+package gobrake
+
+import (
+	"runtime/debug"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseBuildInfo", func() {
+	var bi *debug.BuildInfo
+
+	BeforeEach(func() {
+		bi = &debug.BuildInfo{
+			GoVersion: "go1.22.1",
+			Main: debug.Module{
+				Path:    "github.com/foo/app",
+				Version: "v1.2.3",
+			},
+			Deps: []*debug.Module{
+				{Path: "github.com/pkg/errors", Version: "v0.9.1"},
+				{
+					Path:    "github.com/foo/bar",
+					Version: "v1.0.0",
+					Replace: &debug.Module{Path: "github.com/baz/bar", Version: "v1.0.1"},
+				},
+			},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "0123456789abcdef"},
+				{Key: "vcs.time", Value: "2024-01-02T03:04:05Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}
+	})
+
+	It("reads module version, dependencies and vcs settings", func() {
+		info := parseBuildInfo(bi)
+
+		Expect(info.Path).To(Equal("github.com/foo/app"))
+		Expect(info.mainVersion()).To(Equal("v1.2.3"))
+		Expect(info.Revision).To(Equal("0123456789abcdef"))
+		Expect(info.Time).To(Equal("2024-01-02T03:04:05Z"))
+		Expect(info.Modified).To(BeTrue())
+		Expect(info.Deps).To(Equal(map[string]string{
+			"github.com/pkg/errors": "v0.9.1",
+			"github.com/baz/bar":    "v1.0.1",
+		}))
+	})
+
+	It("returns context", func() {
+		ctx := parseBuildInfo(bi).context()
+
+		Expect(ctx["path"]).To(Equal("github.com/foo/app"))
+		Expect(ctx["version"]).To(Equal("v1.2.3"))
+		Expect(ctx["revision"]).To(Equal("0123456789abcdef"))
+		Expect(ctx["modified"]).To(BeTrue())
+		Expect(ctx["time"]).To(Equal("2024-01-02T03:04:05Z"))
+		Expect(ctx["goVersion"]).To(Equal("go1.22.1"))
+		Expect(ctx).NotTo(HaveKey("dependencies"))
+	})
+
+	It("ignores devel version", func() {
+		bi.Main.Version = "(devel)"
+		bi.Settings = nil
+		info := parseBuildInfo(bi)
+
+		Expect(info.mainVersion()).To(BeEmpty())
+		Expect(info.context()).NotTo(HaveKey("version"))
+		Expect(info.context()).NotTo(HaveKey("revision"))
+	})
+})
+
+var _ = Describe("newBuildDependenciesFilter", func() {
+	var opt *NotifierOptions
+
+	BeforeEach(func() {
+		opt = &NotifierOptions{}
+	})
+
+	notice := func() *Notice {
+		filter := newBuildDependenciesFilter(newSharedOptions(opt))
+		return filter(NewNotice("hello", nil, 0))
+	}
+
+	It("does not attach dependencies by default", func() {
+		notice := notice()
+
+		Expect(notice.Context["buildInfo"]).NotTo(HaveKey("dependencies"))
+	})
+
+	It("attaches dependencies when EnableBuildDependencies is set", func() {
+		opt.EnableBuildDependencies = true
+		notice := notice()
+
+		Expect(notice.Context["buildInfo"]).To(HaveKey("dependencies"))
+		Expect(getDefaultContext()["buildInfo"]).NotTo(HaveKey("dependencies"))
+
+		dropBuildDependencies(notice)
+		Expect(notice.Context["buildInfo"]).NotTo(HaveKey("dependencies"))
+		Expect(notice.Context["buildInfo"]).To(HaveKey("goVersion"))
+	})
+})
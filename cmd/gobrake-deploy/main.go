@@ -0,0 +1,61 @@
+// Command gobrake-deploy notifies Airbrake about a deploy. It is meant to be
+// run from CI pipelines:
+//
+//	gobrake-deploy -project-id 123 -project-key abc -environment production
+//
+// Project id, key, environment and host default to AIRBRAKE_PROJECT_ID,
+// AIRBRAKE_PROJECT_KEY, AIRBRAKE_ENVIRONMENT and AIRBRAKE_HOST. Repository,
+// revision and username default to the git repository in the working
+// directory. The revision falls back to SOURCE_VERSION.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/airbrake/gobrake/v5"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "gobrake-deploy: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	projectId, _ := strconv.ParseInt(os.Getenv("AIRBRAKE_PROJECT_ID"), 10, 64)
+
+	var opt gobrake.NotifierOptions
+	var deploy gobrake.Deploy
+	var timeout time.Duration
+
+	flag.Int64Var(&opt.ProjectId, "project-id", projectId, "Airbrake project id")
+	flag.StringVar(&opt.ProjectKey, "project-key", os.Getenv("AIRBRAKE_PROJECT_KEY"), "Airbrake project key")
+	flag.StringVar(&opt.Host, "host", os.Getenv("AIRBRAKE_HOST"), "Airbrake host")
+	flag.StringVar(&deploy.Environment, "environment", os.Getenv("AIRBRAKE_ENVIRONMENT"), "deploy environment")
+	flag.StringVar(&deploy.Username, "username", "", "user who deployed")
+	flag.StringVar(&deploy.Repository, "repository", "", "repository URL")
+	flag.StringVar(&deploy.Revision, "revision", "", "deployed git revision")
+	flag.StringVar(&deploy.Version, "version", "", "deployed application version")
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "request timeout")
+	flag.Parse()
+
+	if opt.ProjectId == 0 || opt.ProjectKey == "" {
+		return fmt.Errorf("project id and project key are required")
+	}
+
+	opt.DisableRemoteConfig = true
+	opt.DisableBacklog = true
+	notifier := gobrake.NewNotifierWithOptions(&opt)
+	defer notifier.Close()
+
+	c, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return notifier.NotifyDeploy(c, deploy)
+}
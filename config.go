@@ -0,0 +1,260 @@
+package gobrake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix of the environment variables read by OptionsFromEnv.
+const envPrefix = "AIRBRAKE_"
+
+// optionsConfig lists NotifierOptions that can be set in a config file or
+// environment. Environment variable names are envPrefix followed by the
+// upper-cased key, e.g. AIRBRAKE_PROJECT_ID.
+type optionsConfig struct {
+	ProjectId             int64    `json:"project_id"`
+	ProjectKey            string   `json:"project_key"`
+	Environment           string   `json:"environment"`
+	Revision              string   `json:"revision"`
+	Host                  string   `json:"host"`
+	APMHost               string   `json:"apm_host"`
+	RemoteConfigHost      string   `json:"remote_config_host"`
+	RemoteConfigCacheFile string   `json:"remote_config_cache_file"`
+	KeysBlocklist         []string `json:"keys_blocklist"`
+	IgnoredErrorTypes     []string `json:"ignored_error_types"`
+	IgnoredErrorMessages  []string `json:"ignored_error_messages"`
+	IgnoredPackages       []string `json:"ignored_packages"`
+	RouteTemplates        []string `json:"route_templates"`
+	SamplingRate          float64  `json:"sampling_rate"`
+	// Durations such as 30s.
+	APMFlushPeriod            string `json:"apm_flush_period"`
+	APMBucketResolution       string `json:"apm_bucket_resolution"`
+	APMMaxKeys                int    `json:"apm_max_keys"`
+	MaxBreadcrumbs            int    `json:"max_breadcrumbs"`
+	MaxBacktraceDepth         int    `json:"max_backtrace_depth"`
+	MaxGoroutines             int    `json:"max_goroutines"`
+	DisableRemoteConfig       bool   `json:"disable_remote_config"`
+	DisableCodeHunks          bool   `json:"disable_code_hunks"`
+	DisableErrorNotifications bool   `json:"disable_error_notifications"`
+	DisableAPM                bool   `json:"disable_apm"`
+	DisableBacklog            bool   `json:"disable_backlog"`
+	EnableRouteNormalization  bool   `json:"enable_route_normalization"`
+	EnableCompression         bool   `json:"enable_compression"`
+	EnableGoroutineDump       bool   `json:"enable_goroutine_dump"`
+	EnableBuildDependencies   bool   `json:"enable_build_dependencies"`
+	SwallowPanics             bool   `json:"swallow_panics"`
+	Debug                     bool   `json:"debug"`
+}
+
+// OptionsFromEnv returns notifier options read from environment variables:
+//
+//	AIRBRAKE_PROJECT_ID, AIRBRAKE_PROJECT_KEY, AIRBRAKE_ENVIRONMENT,
+//	AIRBRAKE_REVISION, AIRBRAKE_HOST, AIRBRAKE_APM_HOST,
+//	AIRBRAKE_REMOTE_CONFIG_HOST, AIRBRAKE_REMOTE_CONFIG_CACHE_FILE,
+//	AIRBRAKE_KEYS_BLOCKLIST, AIRBRAKE_IGNORED_ERROR_TYPES,
+//	AIRBRAKE_IGNORED_ERROR_MESSAGES, AIRBRAKE_IGNORED_PACKAGES,
+//	AIRBRAKE_ROUTE_TEMPLATES, AIRBRAKE_SAMPLING_RATE, AIRBRAKE_APM_FLUSH_PERIOD,
+//	AIRBRAKE_APM_BUCKET_RESOLUTION, AIRBRAKE_APM_MAX_KEYS,
+//	AIRBRAKE_MAX_BREADCRUMBS, AIRBRAKE_MAX_BACKTRACE_DEPTH,
+//	AIRBRAKE_MAX_GOROUTINES, AIRBRAKE_DISABLE_REMOTE_CONFIG,
+//	AIRBRAKE_DISABLE_CODE_HUNKS, AIRBRAKE_DISABLE_ERROR_NOTIFICATIONS,
+//	AIRBRAKE_DISABLE_APM, AIRBRAKE_DISABLE_BACKLOG,
+//	AIRBRAKE_ENABLE_ROUTE_NORMALIZATION,
+//	AIRBRAKE_ENABLE_COMPRESSION, AIRBRAKE_ENABLE_GOROUTINE_DUMP,
+//	AIRBRAKE_ENABLE_BUILD_DEPENDENCIES, AIRBRAKE_SWALLOW_PANICS and
+//	AIRBRAKE_DEBUG.
+//
+// Lists are comma-separated and keys in AIRBRAKE_KEYS_BLOCKLIST are regular
+// expressions. Booleans are parsed with strconv.ParseBool and durations
+// such as AIRBRAKE_APM_FLUSH_PERIOD with time.ParseDuration. An error is
+// returned when a variable can't be parsed or the options are invalid (see
+// NotifierOptions.Validate).
+func OptionsFromEnv() (*NotifierOptions, error) {
+	var cfg optionsConfig
+	v := reflect.ValueOf(&cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := envPrefix + strings.ToUpper(v.Type().Field(i).Tag.Get("json"))
+		s, ok := os.LookupEnv(name)
+		if !ok || s == "" {
+			continue
+		}
+		if err := setEnvField(v.Field(i), s); err != nil {
+			return nil, fmt.Errorf("gobrake: invalid %s=%q: %s", name, s, err)
+		}
+	}
+	return cfg.options()
+}
+
+func setEnvField(field reflect.Value, s string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		var list []string
+		for _, el := range strings.Split(s, ",") {
+			if el = strings.TrimSpace(el); el != "" {
+				list = append(list, el)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	}
+	return nil
+}
+
+// ConfigDecoder decodes a config file into v, which is a pointer to
+// map[string]interface{}.
+type ConfigDecoder func(data []byte, v interface{}) error
+
+var (
+	configDecodersMu sync.RWMutex
+	configDecoders   = map[string]ConfigDecoder{
+		".json": json.Unmarshal,
+	}
+)
+
+// RegisterConfigDecoder makes OptionsFromFile read files with the extension
+// ext, e.g. ".yaml", using dec. Importing the config/yaml and config/toml
+// packages registers YAML and TOML decoders, so the core package doesn't
+// depend on them.
+func RegisterConfigDecoder(ext string, dec ConfigDecoder) {
+	configDecodersMu.Lock()
+	configDecoders[strings.ToLower(ext)] = dec
+	configDecodersMu.Unlock()
+}
+
+// OptionsFromFile returns notifier options read from a JSON (.json) file or
+// a file with an extension registered with RegisterConfigDecoder, e.g.
+//
+//	import _ "github.com/airbrake/gobrake/v5/config/yaml" // .yaml, .yml
+//	import _ "github.com/airbrake/gobrake/v5/config/toml" // .toml
+//
+// Keys are the snake-cased option names, e.g. project_id, keys_blocklist or
+// apm_flush_period, and are listed in OptionsFromEnv. An error is returned
+// for unknown keys and invalid options (see NotifierOptions.Validate).
+func OptionsFromFile(path string) (*NotifierOptions, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	configDecodersMu.RLock()
+	decode, ok := configDecoders[ext]
+	configDecodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("gobrake: unsupported config file extension %q", ext)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := decode(b, &m); err != nil {
+		return nil, fmt.Errorf("gobrake: parsing %s: %s", path, err)
+	}
+
+	// Decoded values are converted to options through JSON, so unknown
+	// keys are reported the same way for every format.
+	b, err = json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("gobrake: parsing %s: %s", path, err)
+	}
+	var cfg optionsConfig
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("gobrake: parsing %s: %s", path, err)
+	}
+	return cfg.options()
+}
+
+func (cfg *optionsConfig) options() (*NotifierOptions, error) {
+	opt := &NotifierOptions{
+		ProjectId:                 cfg.ProjectId,
+		ProjectKey:                cfg.ProjectKey,
+		Environment:               cfg.Environment,
+		Revision:                  cfg.Revision,
+		Host:                      cfg.Host,
+		APMHost:                   cfg.APMHost,
+		RemoteConfigHost:          cfg.RemoteConfigHost,
+		RemoteConfigCacheFile:     cfg.RemoteConfigCacheFile,
+		IgnoredErrorTypes:         cfg.IgnoredErrorTypes,
+		IgnoredErrorMessages:      cfg.IgnoredErrorMessages,
+		IgnoredPackages:           cfg.IgnoredPackages,
+		RouteTemplates:            cfg.RouteTemplates,
+		SamplingRate:              cfg.SamplingRate,
+		APMMaxKeys:                cfg.APMMaxKeys,
+		MaxBreadcrumbs:            cfg.MaxBreadcrumbs,
+		MaxBacktraceDepth:         cfg.MaxBacktraceDepth,
+		MaxGoroutines:             cfg.MaxGoroutines,
+		DisableRemoteConfig:       cfg.DisableRemoteConfig,
+		DisableCodeHunks:          cfg.DisableCodeHunks,
+		DisableErrorNotifications: cfg.DisableErrorNotifications,
+		DisableAPM:                cfg.DisableAPM,
+		DisableBacklog:            cfg.DisableBacklog,
+		EnableRouteNormalization:  cfg.EnableRouteNormalization,
+		EnableCompression:         cfg.EnableCompression,
+		EnableGoroutineDump:       cfg.EnableGoroutineDump,
+		EnableBuildDependencies:   cfg.EnableBuildDependencies,
+		SwallowPanics:             cfg.SwallowPanics,
+		Debug:                     cfg.Debug,
+	}
+
+	// Keys are regular expressions appended to the default blocklist like
+	// in the remote config.
+	if len(cfg.KeysBlocklist) > 0 {
+		opt.KeysBlocklist = defaultKeysBlocklist()
+	}
+	for _, key := range cfg.KeysBlocklist {
+		re, err := regexp.Compile(key)
+		if err != nil {
+			return nil, fmt.Errorf("gobrake: invalid keys_blocklist entry %q: %s", key, err)
+		}
+		opt.KeysBlocklist = append(opt.KeysBlocklist, re)
+	}
+
+	if cfg.APMFlushPeriod != "" {
+		d, err := time.ParseDuration(cfg.APMFlushPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("gobrake: invalid apm_flush_period: %s", err)
+		}
+		opt.APMFlushPeriod = d
+	}
+
+	if cfg.APMBucketResolution != "" {
+		d, err := time.ParseDuration(cfg.APMBucketResolution)
+		if err != nil {
+			return nil, fmt.Errorf("gobrake: invalid apm_bucket_resolution: %s", err)
+		}
+		opt.APMBucketResolution = d
+	}
+
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+	return opt, nil
+}
@@ -0,0 +1,19 @@
+// Package toml makes gobrake.OptionsFromFile read TOML (.toml) files.
+// Import it for its side effect:
+//
+//	import _ "github.com/airbrake/gobrake/v5/config/toml"
+package toml
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/airbrake/gobrake/v5"
+)
+
+func init() {
+	gobrake.RegisterConfigDecoder(".toml", Decode)
+}
+
+// Decode decodes the TOML document data into v.
+func Decode(data []byte, v interface{}) error {
+	return toml.Unmarshal(data, v)
+}
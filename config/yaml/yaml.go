@@ -0,0 +1,20 @@
+// Package yaml makes gobrake.OptionsFromFile read YAML (.yaml, .yml) files.
+// Import it for its side effect:
+//
+//	import _ "github.com/airbrake/gobrake/v5/config/yaml"
+package yaml
+
+import (
+	"github.com/airbrake/gobrake/v5"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	gobrake.RegisterConfigDecoder(".yaml", Decode)
+	gobrake.RegisterConfigDecoder(".yml", Decode)
+}
+
+// Decode decodes the YAML document data into v.
+func Decode(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
@@ -0,0 +1,150 @@
+package gobrake_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/airbrake/gobrake/v5"
+	_ "github.com/airbrake/gobrake/v5/config/toml"
+	_ "github.com/airbrake/gobrake/v5/config/yaml"
+)
+
+var _ = Describe("OptionsFromEnv", func() {
+	setenv := func(key, value string) {
+		Expect(os.Setenv(key, value)).To(Succeed())
+		DeferCleanup(os.Unsetenv, key)
+	}
+
+	It("reads options from AIRBRAKE_ variables", func() {
+		setenv("AIRBRAKE_PROJECT_ID", "123")
+		setenv("AIRBRAKE_PROJECT_KEY", "key")
+		setenv("AIRBRAKE_ENVIRONMENT", "production")
+		setenv("AIRBRAKE_HOST", "https://example.com")
+		setenv("AIRBRAKE_KEYS_BLOCKLIST", "password, token")
+		setenv("AIRBRAKE_SAMPLING_RATE", "0.5")
+		setenv("AIRBRAKE_APM_FLUSH_PERIOD", "30s")
+		setenv("AIRBRAKE_APM_BUCKET_RESOLUTION", "10s")
+		setenv("AIRBRAKE_APM_MAX_KEYS", "500")
+		setenv("AIRBRAKE_DISABLE_APM", "true")
+
+		opt, err := gobrake.OptionsFromEnv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opt.ProjectId).To(Equal(int64(123)))
+		Expect(opt.ProjectKey).To(Equal("key"))
+		Expect(opt.Environment).To(Equal("production"))
+		Expect(opt.Host).To(Equal("https://example.com"))
+		Expect(opt.KeysBlocklist).To(Equal([]interface{}{
+			regexp.MustCompile("password"),
+			regexp.MustCompile("secret"),
+			regexp.MustCompile("password"),
+			regexp.MustCompile("token"),
+		}))
+		Expect(opt.SamplingRate).To(Equal(0.5))
+		Expect(opt.APMFlushPeriod).To(Equal(30 * time.Second))
+		Expect(opt.APMBucketResolution).To(Equal(10 * time.Second))
+		Expect(opt.APMMaxKeys).To(Equal(500))
+		Expect(opt.DisableAPM).To(BeTrue())
+	})
+
+	It("returns an error when project key is missing", func() {
+		setenv("AIRBRAKE_PROJECT_ID", "123")
+
+		_, err := gobrake.OptionsFromEnv()
+		Expect(err).To(MatchError("gobrake: invalid options: ProjectKey is required"))
+	})
+
+	It("returns an error for invalid values", func() {
+		setenv("AIRBRAKE_PROJECT_ID", "abc")
+
+		_, err := gobrake.OptionsFromEnv()
+		Expect(err).To(MatchError(ContainSubstring("invalid AIRBRAKE_PROJECT_ID")))
+	})
+
+	It("returns an error for invalid keys blocklist regexps", func() {
+		setenv("AIRBRAKE_PROJECT_ID", "123")
+		setenv("AIRBRAKE_PROJECT_KEY", "key")
+		setenv("AIRBRAKE_KEYS_BLOCKLIST", "(?i)auth, (token")
+
+		_, err := gobrake.OptionsFromEnv()
+		Expect(err).To(MatchError(ContainSubstring(`invalid keys_blocklist entry "(token"`)))
+	})
+})
+
+var _ = Describe("OptionsFromFile", func() {
+	writeFile := func(name, content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), name)
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+		return path
+	}
+
+	expectOptions := func(opt *gobrake.NotifierOptions, err error) {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opt.ProjectId).To(Equal(int64(123)))
+		Expect(opt.ProjectKey).To(Equal("key"))
+		Expect(opt.Environment).To(Equal("staging"))
+		Expect(opt.IgnoredErrorTypes).To(Equal([]string{"*url.Error"}))
+		Expect(opt.KeysBlocklist).To(Equal([]interface{}{
+			regexp.MustCompile("password"),
+			regexp.MustCompile("secret"),
+			regexp.MustCompile("(?i)token"),
+		}))
+		Expect(opt.APMFlushPeriod).To(Equal(time.Minute))
+	}
+
+	It("reads YAML", func() {
+		expectOptions(gobrake.OptionsFromFile(writeFile("airbrake.yml", `
+project_id: 123
+project_key: key
+environment: staging
+ignored_error_types: ["*url.Error"]
+keys_blocklist: ["(?i)token"]
+apm_flush_period: 1m
+`)))
+	})
+
+	It("reads JSON", func() {
+		expectOptions(gobrake.OptionsFromFile(writeFile("airbrake.json", `{
+  "project_id": 123,
+  "project_key": "key",
+  "environment": "staging",
+  "ignored_error_types": ["*url.Error"],
+  "keys_blocklist": ["(?i)token"],
+  "apm_flush_period": "1m"
+}`)))
+	})
+
+	It("reads TOML", func() {
+		expectOptions(gobrake.OptionsFromFile(writeFile("airbrake.toml", `
+project_id = 123
+project_key = "key"
+environment = "staging"
+ignored_error_types = ["*url.Error"]
+keys_blocklist = ["(?i)token"]
+apm_flush_period = "1m"
+`)))
+	})
+
+	It("returns an error for unknown keys", func() {
+		_, err := gobrake.OptionsFromFile(writeFile("airbrake.yaml", `
+project_id: 123
+project_key: key
+enviroment: staging
+`))
+		Expect(err).To(MatchError(ContainSubstring("enviroment")))
+	})
+
+	It("returns an error when project id is missing", func() {
+		_, err := gobrake.OptionsFromFile(writeFile("airbrake.toml", `project_key = "key"`))
+		Expect(err).To(MatchError("gobrake: invalid options: ProjectId is required"))
+	})
+
+	It("returns an error for unsupported extensions", func() {
+		_, err := gobrake.OptionsFromFile(writeFile("airbrake.ini", ""))
+		Expect(err).To(MatchError(`gobrake: unsupported config file extension ".ini"`))
+	})
+})
@@ -0,0 +1,200 @@
+package gobrake
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Set for the process started by the crash handler.
+const crashMonitorEnv = "GOBRAKE_CRASH_MONITOR"
+
+// crashHandlerChild is true in the process started by the crash handler.
+// The variable is unset when the package is initialized, so processes
+// started by the program don't inherit it and skip their own crash handler.
+var crashHandlerChild = func() bool {
+	_, ok := os.LookupEnv(crashMonitorEnv)
+	_ = os.Unsetenv(crashMonitorEnv)
+	return ok
+}()
+
+// How much of the crash output is kept for parsing.
+const maxCrashOutputLen = 1 << 20
+
+const spooledNoticeExt = ".json"
+
+// CrashHandlerOptions configures Notifier.StartCrashHandler.
+type CrashHandlerOptions struct {
+	// Directory where crash notices that could not be delivered are stored
+	// until the next start. Default is gobrake-crashes in os.TempDir().
+	SpoolDir string
+}
+
+func (opt *CrashHandlerOptions) spoolDir() string {
+	if opt != nil && opt.SpoolDir != "" {
+		return opt.SpoolDir
+	}
+	return filepath.Join(os.TempDir(), "gobrake-crashes")
+}
+
+// StartCrashHandler reports unrecovered panics and fatal errors that kill
+// the process. The program is started again as a monitor that parses the
+// crash output and sends it to Airbrake. On Go 1.23 and later the monitor
+// receives the crash output via debug.SetCrashOutput, on earlier versions
+// the monitor runs the program as a child process and watches its stderr.
+// Crash notices that could not be sent are spooled to disk and sent on the
+// next start.
+//
+// StartCrashHandler must be called at the beginning of main, because the
+// code before it, including NewNotifier, is executed by the monitor too.
+// The monitor stops polling the remote config, but other side effects of
+// that code happen in both processes.
+func (n *Notifier) StartCrashHandler(opt *CrashHandlerOptions) error {
+	spoolDir := opt.spoolDir()
+	if err := n.startCrashHandler(spoolDir); err != nil {
+		return err
+	}
+
+	go n.sendSpooledNotices(spoolDir)
+	return nil
+}
+
+// deliverCrash parses the crash output and sends the crash notice. The
+// notice is spooled when it can't be sent.
+func (n *Notifier) deliverCrash(output []byte, spoolDir string) {
+	notice, ok := n.parseCrash(output)
+	if !ok {
+		return
+	}
+
+	_, err := n.SendNotice(notice)
+	if err == nil {
+		return
+	}
+	log := n.opts.Load().log()
+	log.Error("SendNotice failed reporting crash", "notice", notice, "error", err)
+
+	if err := spoolNotice(notice, spoolDir); err != nil {
+		log.Error("spoolNotice failed", "error", err)
+	}
+}
+
+// parseCrash returns notice created from the panic or fatal error output of
+// the Go runtime.
+func (n *Notifier) parseCrash(output []byte) (*Notice, bool) {
+	typ, msg, start := findCrashHeader(output)
+	if start == -1 {
+		return nil, false
+	}
+
+	notice := NewNotice(msg, nil, -1)
+	notice.Errors[0].Type = typ
+	notice.Context["severity"] = "critical"
+	notice.Context["crash"] = true
+
+	goroutines := parseGoroutines(output[start:], maxCollectedFrames)
+	if len(goroutines) > 0 {
+		notice.Errors[0].Backtrace = goroutines[0].Frames
+		if len(goroutines[0].Frames) > 0 {
+			notice.Context["component"] = goroutines[0].Frames[0].pkg
+		}
+
+		others := goroutines[1:]
+		if max := n.opts.Load().MaxGoroutines; len(others) > max {
+			others = others[:max]
+		}
+		for i := range others {
+			if len(others[i].Frames) > maxGoroutineFrames {
+				others[i].Frames = others[i].Frames[:maxGoroutineFrames]
+			}
+		}
+		if len(others) > 0 {
+			notice.Context["goroutines"] = others
+			notice.Context["goroutinesCount"] = len(goroutines)
+		}
+	}
+
+	return notice, true
+}
+
+// findCrashHeader finds the first "panic: " or "fatal error: " line and
+// returns the error type, the message and the offset of the message.
+func findCrashHeader(output []byte) (string, string, int) {
+	var offset int
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxCrashOutputLen)
+	for scanner.Scan() {
+		line := scanner.Text()
+		start := offset
+		offset += len(line) + 1
+
+		for _, typ := range []string{"panic", "fatal error"} {
+			if !strings.HasPrefix(line, typ+": ") {
+				continue
+			}
+
+			msg := []string{line[len(typ)+2:]}
+			// Multi-line messages and nested panics end with a blank line.
+			for scanner.Scan() {
+				line := scanner.Text()
+				if line == "" || strings.HasPrefix(line, "goroutine ") {
+					break
+				}
+				msg = append(msg, strings.TrimSpace(line))
+			}
+			return typ, strings.Join(msg, "\n"), start
+		}
+	}
+	return "", "", -1
+}
+
+func spoolNotice(notice *Notice, dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(notice)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("notice-%d-%d%s", clock.Now().UnixNano(), os.Getpid(), spooledNoticeExt)
+	tmp := filepath.Join(dir, name+".tmp")
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, name))
+}
+
+// sendSpooledNotices sends notices spooled by the previous runs. Notices are
+// removed once they are sent.
+func (n *Notifier) sendSpooledNotices(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "notice-*"+spooledNoticeExt))
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		notice := new(Notice)
+		if err := json.Unmarshal(b, notice); err != nil {
+			n.opts.Load().log().Warn("removing invalid spooled notice", "file", file, "error", err)
+			_ = os.Remove(file)
+			continue
+		}
+
+		if _, err := n.SendNotice(notice); err != nil {
+			n.opts.Load().log().Error("SendNotice failed reporting spooled notice", "notice", notice, "error", err)
+			return
+		}
+		_ = os.Remove(file)
+	}
+}
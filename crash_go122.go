@@ -0,0 +1,79 @@
+//go:build !go1.23
+// +build !go1.23
+
+package gobrake
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// startCrashHandler runs the program as a child process watching its stderr
+// for the crash output. The child process returns from startCrashHandler,
+// the parent process exits with the exit code of the child.
+func (n *Notifier) startCrashHandler(spoolDir string) error {
+	if crashHandlerChild {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	stderr := &tailBuffer{max: maxCrashOutputLen}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), crashMonitorEnv+"=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+
+	// Only the child process needs the remote config.
+	n.remoteConfig.StopPolling()
+
+	_ = cmd.Wait()
+	signal.Stop(signals)
+
+	code := cmd.ProcessState.ExitCode()
+	if code != 0 {
+		n.deliverCrash(stderr.Bytes(), spoolDir)
+	}
+	if code == -1 {
+		// Killed by a signal.
+		code = 1
+	}
+	os.Exit(code)
+	return nil
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	buf []byte
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	return b.buf
+}
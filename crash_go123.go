@@ -0,0 +1,64 @@
+//go:build go1.23
+// +build go1.23
+
+package gobrake
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime/debug"
+)
+
+// startCrashHandler starts the program as a monitor process and sends the
+// crash output to it with debug.SetCrashOutput. In the monitor process it
+// waits for the crash output and never returns.
+func (n *Notifier) startCrashHandler(spoolDir string) error {
+	if crashHandlerChild {
+		n.runCrashMonitor(spoolDir)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), crashMonitorEnv+"=1")
+	cmd.Stdin = r
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	err = cmd.Start()
+	r.Close()
+	if err != nil {
+		return err
+	}
+	// The monitor exits after this process.
+	_ = cmd.Process.Release()
+
+	return debug.SetCrashOutput(w, debug.CrashOptions{})
+}
+
+func (n *Notifier) runCrashMonitor(spoolDir string) {
+	// Interrupts are sent to the whole process group. The monitor must
+	// outlive the monitored process.
+	signal.Ignore(os.Interrupt)
+	// Only the monitored process needs the remote config.
+	n.remoteConfig.StopPolling()
+
+	output, err := io.ReadAll(io.LimitReader(os.Stdin, maxCrashOutputLen))
+	if err != nil {
+		n.opts.Load().log().Error("crash monitor failed", "error", err)
+	}
+	if len(output) > 0 {
+		n.deliverCrash(output, spoolDir)
+	}
+	os.Exit(0)
+}
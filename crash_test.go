@@ -0,0 +1,192 @@
+package gobrake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("crash handler", func() {
+	var notifier *Notifier
+	var status int32
+	var sent int32
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&status, http.StatusCreated)
+		atomic.StoreInt32(&sent, 0)
+
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&sent, 1)
+			w.WriteHeader(int(atomic.LoadInt32(&status)))
+			_, _ = w.Write([]byte(`{"id":"123"}`))
+		}
+		server := httptest.NewServer(http.HandlerFunc(handler))
+		DeferCleanup(server.Close)
+
+		notifier = NewNotifierWithOptions(&NotifierOptions{
+			ProjectId:           1,
+			ProjectKey:          "key",
+			Host:                server.URL,
+			DisableRemoteConfig: true,
+			DisableBacklog:      true,
+		})
+		DeferCleanup(notifier.Close)
+	})
+
+	It("parses panic output", func() {
+		output := `some log line
+panic: boom [recovered]
+	panic: boom
+
+goroutine 1 [running]:
+main.handle(...)
+	/src/app/main.go:20
+main.main()
+	/src/app/main.go:10 +0x1d
+
+goroutine 7 [chan receive]:
+main.worker()
+	/src/app/worker.go:5 +0x25
+created by main.main in goroutine 1
+	/src/app/main.go:8 +0x65
+exit status 2
+`
+
+		notice, ok := notifier.parseCrash([]byte(output))
+		Expect(ok).To(BeTrue())
+
+		e := notice.Errors[0]
+		Expect(e.Type).To(Equal("panic"))
+		Expect(e.Message).To(Equal("boom [recovered]\npanic: boom"))
+		Expect(e.Backtrace).To(HaveLen(2))
+		Expect(e.Backtrace[0].File).To(Equal("/src/app/main.go"))
+		Expect(e.Backtrace[0].Line).To(Equal(20))
+		Expect(e.Backtrace[0].Func).To(Equal("handle"))
+
+		Expect(notice.Context["severity"]).To(Equal("critical"))
+		Expect(notice.Context["component"]).To(Equal("main"))
+		goroutines := notice.Context["goroutines"].([]Goroutine)
+		Expect(goroutines).To(HaveLen(1))
+		Expect(goroutines[0].ID).To(Equal(7))
+	})
+
+	It("parses fatal errors", func() {
+		output := `fatal error: all goroutines are asleep - deadlock!
+
+goroutine 1 [chan receive]:
+main.main()
+	/src/app/main.go:10 +0x1d
+`
+
+		notice, ok := notifier.parseCrash([]byte(output))
+		Expect(ok).To(BeTrue())
+		Expect(notice.Errors[0].Type).To(Equal("fatal error"))
+		Expect(notice.Errors[0].Message).To(Equal("all goroutines are asleep - deadlock!"))
+		Expect(notice.Context).NotTo(HaveKey("goroutines"))
+	})
+
+	It("ignores output without crash", func() {
+		_, ok := notifier.parseCrash([]byte("exit status 1\n"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("spools notices that can't be sent and sends them later", func() {
+		dir := GinkgoT().TempDir()
+		output := []byte("panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:1 +0x1\n")
+
+		atomic.StoreInt32(&status, http.StatusInternalServerError)
+		notifier.deliverCrash(output, dir)
+		files, _ := filepath.Glob(filepath.Join(dir, "*"))
+		Expect(files).To(HaveLen(1))
+
+		atomic.StoreInt32(&status, http.StatusCreated)
+		notifier.sendSpooledNotices(dir)
+		Expect(atomic.LoadInt32(&sent)).To(Equal(int32(2)))
+		_, err := os.Stat(files[0])
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
+
+// Set for the test binary started by "reports crashes of the process".
+const crashTestHostEnv = "GOBRAKE_TEST_CRASH_HOST"
+
+// TestCrashHandlerProcess is run in a subprocess. It starts the crash handler
+// and panics.
+func TestCrashHandlerProcess(t *testing.T) {
+	host := os.Getenv(crashTestHostEnv)
+	if host == "" {
+		t.Skip("run by the crash handler spec")
+	}
+
+	fmt.Printf("%s=%q\n", crashMonitorEnv, os.Getenv(crashMonitorEnv))
+
+	notifier := NewNotifierWithOptions(&NotifierOptions{
+		ProjectId:           1,
+		ProjectKey:          "key",
+		Host:                host,
+		DisableRemoteConfig: true,
+		DisableBacklog:      true,
+	})
+	err := notifier.StartCrashHandler(&CrashHandlerOptions{
+		SpoolDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	panic("crash handler test")
+}
+
+var _ = Describe("StartCrashHandler", func() {
+	It("reports crashes of the process", func() {
+		var mu sync.Mutex
+		var notices []*Notice
+		server := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, req *http.Request) {
+				b, _ := io.ReadAll(req.Body)
+				notice := new(Notice)
+				_ = json.Unmarshal(b, notice)
+
+				mu.Lock()
+				notices = append(notices, notice)
+				mu.Unlock()
+
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id":"123"}`))
+			},
+		))
+		DeferCleanup(server.Close)
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCrashHandlerProcess$")
+		cmd.Env = append(os.Environ(), crashTestHostEnv+"="+server.URL)
+		// Waits for the monitor too, because it shares the output.
+		output, err := cmd.CombinedOutput()
+		Expect(err).To(HaveOccurred())
+		Expect(string(output)).To(ContainSubstring("panic: crash handler test"))
+		// The variable is not visible to the program.
+		Expect(string(output)).NotTo(ContainSubstring(crashMonitorEnv + `="1"`))
+
+		Eventually(func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(notices)
+		}, "10s").Should(Equal(1))
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(notices[0].Errors[0].Type).To(Equal("panic"))
+		Expect(notices[0].Errors[0].Message).To(HavePrefix("crash handler test"))
+		Expect(notices[0].Context["severity"]).To(Equal("critical"))
+	})
+})
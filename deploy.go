@@ -0,0 +1,120 @@
+package gobrake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Deploy describes an application deploy.
+type Deploy struct {
+	Environment string `json:"environment"`
+	Username    string `json:"username"`
+	Repository  string `json:"repository"`
+	Revision    string `json:"revision"`
+	Version     string `json:"version,omitempty"`
+}
+
+// NotifyDeploy notifies Airbrake about the deploy. Empty fields are filled
+// from the notifier environment, the git repository in the working directory
+// and SOURCE_VERSION.
+func (n *Notifier) NotifyDeploy(c context.Context, deploy Deploy) error {
+	opt := n.opts.Load()
+	n.fillDeploy(&deploy)
+
+	buf := buffers.Get().(*bytes.Buffer)
+	defer buffers.Put(buf)
+	buf.Reset()
+
+	err := json.NewEncoder(buf).Encode(deploy)
+	if err != nil {
+		return err
+	}
+
+	req, err := newRequest(
+		opt,
+		http.MethodPost,
+		fmt.Sprintf("%s/api/v4/projects/%d/deploys",
+			opt.Host, opt.ProjectId),
+		buf,
+	)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(c)
+
+	resp, err := opt.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf.Reset()
+	_, err = buf.ReadFrom(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return errUnauthorized
+	case http.StatusBadRequest, http.StatusTooManyRequests:
+		var sendResp sendResponse
+		err = json.NewDecoder(buf).Decode(&sendResp)
+		if err != nil {
+			return err
+		}
+		return errors.New(sendResp.Message)
+	}
+
+	return fmt.Errorf("got unexpected response status=%q", resp.Status)
+}
+
+// fillDeploy fills empty deploy fields. The revision is read from the git
+// repository in the working directory and then from SOURCE_VERSION, but not
+// from NotifierOptions.Revision which may be the build revision of the
+// program notifying about the deploy.
+func (n *Notifier) fillDeploy(deploy *Deploy) {
+	opt := n.opts.Load()
+	if deploy.Environment == "" {
+		deploy.Environment = opt.Environment
+	}
+	defer func() {
+		if deploy.Revision == "" {
+			// https://devcenter.heroku.com/changelog-items/630
+			deploy.Revision = os.Getenv("SOURCE_VERSION")
+		}
+	}()
+
+	if deploy.Repository != "" && deploy.Revision != "" && deploy.Username != "" {
+		return
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	gitDir, ok := findGitDir(wd)
+	if !ok {
+		return
+	}
+	info := getGitInfo(gitDir, opt.log())
+
+	if deploy.Repository == "" {
+		deploy.Repository = info.Repository
+	}
+	if deploy.Revision == "" {
+		deploy.Revision = info.Revision
+	}
+	if deploy.Username == "" && info.LastCheckout != nil {
+		deploy.Username = info.LastCheckout.Username
+	}
+}
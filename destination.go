@@ -0,0 +1,347 @@
+package gobrake
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Destination is an additional Airbrake project that notices and APM stats
+// are delivered to. Notices pass the notifier filters once and are then
+// delivered to the notifier project and to every destination.
+type Destination struct {
+	// Airbrake project id.
+	ProjectId int64
+
+	// Airbrake project key.
+	ProjectKey string
+
+	// Airbrake host name. Default is NotifierOptions.Host.
+	Host string
+
+	// Airbrake host name for sending APM data. Default is Host if it is
+	// set, otherwise NotifierOptions.APMHost.
+	APMHost string
+
+	// Filters applied only to notices delivered to this destination. They
+	// receive a copy of the notice, but nested values are shared with the
+	// other destinations and must not be modified in place.
+	Filters []func(*Notice) *Notice
+}
+
+// destination delivers notices and APM stats to a single Airbrake project.
+// Every destination has its own rate limiting and backlogs.
+type destination struct {
+	opts *sharedOptions
+	// nil for the notifier project.
+	cfg *Destination
+	// Tracks deliveries to destinations other than the notifier project.
+	wg *sync.WaitGroup
+
+	rateLimitReset uint32 // atomic
+
+	nb *noticeBacklog
+	ab *apmBacklog
+}
+
+type destinations []*destination
+
+// newDestinations returns the destination of the notifier project followed
+// by the destinations from opt.Destinations. Deliveries to the latter are
+// asynchronous and tracked by wg.
+func newDestinations(
+	opts *sharedOptions, opt *NotifierOptions, wg *sync.WaitGroup,
+) destinations {
+	ds := destinations{newDestination(opts, nil, wg)}
+	for i := range opt.Destinations {
+		cfg := opt.Destinations[i]
+		ds = append(ds, newDestination(opts, &cfg, wg))
+	}
+	return ds
+}
+
+func newDestination(opts *sharedOptions, cfg *Destination, wg *sync.WaitGroup) *destination {
+	d := &destination{
+		opts: opts,
+		cfg:  cfg,
+		wg:   wg,
+	}
+	d.nb = &noticeBacklog{dest: d}
+	d.ab = &apmBacklog{dest: d}
+	return d
+}
+
+// options returns the notifier options with the project and hosts of the
+// destination.
+func (d *destination) options() *NotifierOptions {
+	opt := d.opts.Load()
+	if d.cfg == nil {
+		return opt
+	}
+
+	opt = opt.Copy()
+	opt.ProjectId = d.cfg.ProjectId
+	opt.ProjectKey = d.cfg.ProjectKey
+	if d.cfg.Host != "" {
+		opt.Host = d.cfg.Host
+		opt.APMHost = d.cfg.Host
+	}
+	if d.cfg.APMHost != "" {
+		opt.APMHost = d.cfg.APMHost
+	}
+	return opt
+}
+
+func (d *destination) sendNotice(notice *Notice) (string, error) {
+	if d.cfg != nil {
+		for _, fn := range d.cfg.Filters {
+			notice = fn(notice)
+			if notice == nil {
+				// Notice is ignored.
+				return "", nil
+			}
+		}
+	}
+
+	if time.Now().Unix() < int64(atomic.LoadUint32(&d.rateLimitReset)) {
+		return "", errIPRateLimited
+	}
+
+	opt := d.options()
+
+	buf := buffers.Get().(*bytes.Buffer)
+	defer buffers.Put(buf)
+
+	buf.Reset()
+	err := json.NewEncoder(buf).Encode(notice)
+	if err != nil {
+		return "", err
+	}
+
+	if buf.Len() > maxNoticeLen {
+		err = truncateNotice(notice, buf)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	url := fmt.Sprintf("%s/api/v3/projects/%d/notices", opt.Host, opt.ProjectId)
+	if opt.Debug {
+		opt.log().Debug("sending notice",
+			"url", url, "notice", notice, "bytes", buf.Len())
+	}
+
+	req, err := newRequest(opt, http.MethodPost, url, buf)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := opt.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	buf.Reset()
+	_, err = buf.ReadFrom(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		var sendResp sendResponse
+		err = json.NewDecoder(buf).Decode(&sendResp)
+		if err != nil {
+			return "", err
+		}
+		return sendResp.Id, nil
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return "", errUnauthorized
+	case http.StatusTooManyRequests:
+		delayStr := resp.Header.Get("X-RateLimit-Delay")
+		delay, err := strconv.ParseInt(delayStr, 10, 64)
+		if err == nil {
+			atomic.StoreUint32(&d.rateLimitReset, uint32(time.Now().Unix()+delay))
+		}
+		var sendResp sendResponse
+		err = json.NewDecoder(buf).Decode(&sendResp)
+		if err != nil {
+			return "", err
+		}
+		return "", errors.New(sendResp.Message)
+	case httpEnhanceYourCalm:
+		return "", errAccountRateLimited
+	case http.StatusRequestEntityTooLarge:
+		return "", errNoticeTooBig
+	case http.StatusBadRequest:
+		var sendResp sendResponse
+		err = json.NewDecoder(buf).Decode(&sendResp)
+		if err != nil {
+			return "", err
+		}
+		return "", errors.New(sendResp.Message)
+	default:
+		if isBacklogStatus(resp.StatusCode) {
+			d.nb.add(notice)
+		}
+	}
+
+	err = fmt.Errorf("got unexpected response status=%q", resp.Status)
+	opt.log().Error("SendNotice failed", "notice", notice, "error", err)
+	return "", err
+}
+
+// sendNotice delivers notice to every destination and returns the result
+// of the notifier project. Other destinations are sent to concurrently
+// after the notifier project and their errors are logged.
+func (ds destinations) sendNotice(notice *Notice) (string, error) {
+	// Destination filters and truncation change the notice.
+	clones := make([]*Notice, len(ds)-1)
+	for i := range clones {
+		clones[i] = cloneNotice(notice)
+	}
+
+	id, err := ds[0].sendNotice(notice)
+
+	for i, d := range ds[1:] {
+		d, notice := d, clones[i]
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+
+			_, err := d.sendNotice(notice)
+			if err != nil {
+				d.options().log().Error(
+					"sendNotice failed",
+					"notice", notice, "project_id", d.cfg.ProjectId, "error", err,
+				)
+			}
+		}()
+	}
+	return id, err
+}
+
+// sendAPM sends APM stats to path, e.g. routes-stats, of every destination.
+// It returns the error of the notifier project. Other destinations are sent
+// to concurrently after the notifier project and their errors are logged.
+func (ds destinations) sendAPM(path string, out interface{}) error {
+	body, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	err = ds[0].sendAPM(path, out, body)
+
+	for _, d := range ds[1:] {
+		d := d
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+
+			err := d.sendAPM(path, out, body)
+			if err != nil {
+				d.options().log().Error(
+					"sending APM stats failed",
+					"path", path, "project_id", d.cfg.ProjectId, "error", err,
+				)
+			}
+		}()
+	}
+	return err
+}
+
+func (d *destination) sendAPM(path string, out interface{}, body []byte) error {
+	opt := d.options()
+
+	url := fmt.Sprintf("%s/api/v5/projects/%d/%s", opt.APMHost, opt.ProjectId, path)
+	if opt.Debug {
+		opt.log().Debug("sending APM stats", "url", url, "bytes", len(body))
+	}
+
+	req, err := newRequest(opt, http.MethodPut, url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	resp, err := opt.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf := buffers.Get().(*bytes.Buffer)
+	defer buffers.Put(buf)
+
+	buf.Reset()
+	_, err = buf.ReadFrom(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return errUnauthorized
+	case http.StatusBadRequest, http.StatusTooManyRequests:
+		var sendResp sendResponse
+		err = json.NewDecoder(buf).Decode(&sendResp)
+		if err != nil {
+			return err
+		}
+		return errors.New(sendResp.Message)
+	default:
+		if isBacklogStatus(resp.StatusCode) {
+			d.ab.add(path, out)
+		}
+	}
+
+	err = fmt.Errorf("got unexpected response status=%q", resp.Status)
+	return err
+}
+
+// close stops the backlog timers of every destination.
+func (ds destinations) close() {
+	for _, d := range ds {
+		d.nb.close()
+		d.ab.close()
+	}
+}
+
+// cloneNotice returns a copy of the notice that can be changed by filters
+// and truncation without affecting the original.
+func cloneNotice(notice *Notice) *Notice {
+	c := *notice
+	c.Errors = make([]Error, len(notice.Errors))
+	for i, e := range notice.Errors {
+		e.Backtrace = append([]StackFrame(nil), e.Backtrace...)
+		c.Errors[i] = e
+	}
+	c.Context = cloneMap(notice.Context)
+	c.Env = cloneMap(notice.Env)
+	c.Session = cloneMap(notice.Session)
+	c.Params = cloneMap(notice.Params)
+	return &c
+}
+
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,194 @@
+package gobrake_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/airbrake/gobrake/v5"
+)
+
+var _ = Describe("Destinations", func() {
+	var notifier *gobrake.Notifier
+	var opt *gobrake.NotifierOptions
+
+	var mu sync.Mutex
+	var notices map[string][]*gobrake.Notice
+	var apmRequests map[string]int
+
+	received := func(path string) []*gobrake.Notice {
+		mu.Lock()
+		defer mu.Unlock()
+		return notices[path]
+	}
+
+	BeforeEach(func() {
+		notices = make(map[string][]*gobrake.Notice)
+		apmRequests = make(map[string]int)
+
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			b, err := io.ReadAll(req.Body)
+			Expect(err).To(BeNil())
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if req.Method == http.MethodPut {
+				apmRequests[req.URL.Path]++
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+
+			notice := new(gobrake.Notice)
+			Expect(json.Unmarshal(b, notice)).To(Succeed())
+			notices[req.URL.Path] = append(notices[req.URL.Path], notice)
+
+			if req.URL.Path == "/api/v3/projects/3/notices" {
+				w.Header().Set("X-RateLimit-Delay", "10")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"message":"rate limited"}`))
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"123"}`))
+		}
+		server := httptest.NewServer(http.HandlerFunc(handler))
+		configServer := newConfigServer()
+
+		opt = &gobrake.NotifierOptions{
+			ProjectId:        1,
+			ProjectKey:       "key",
+			Host:             server.URL,
+			RemoteConfigHost: configServer.URL,
+			Destinations: []gobrake.Destination{{
+				ProjectId:  2,
+				ProjectKey: "key2",
+				Filters: []func(*gobrake.Notice) *gobrake.Notice{
+					func(notice *gobrake.Notice) *gobrake.Notice {
+						notice.Context["team"] = "platform"
+						return notice
+					},
+				},
+			}, {
+				ProjectId:  3,
+				ProjectKey: "key3",
+			}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		notifier = gobrake.NewNotifierWithOptions(opt)
+	})
+
+	AfterEach(func() {
+		Expect(notifier.Close()).NotTo(HaveOccurred())
+	})
+
+	It("delivers notices to every destination", func() {
+		id, err := notifier.SendNotice(notifier.Notice("hello", nil, 0))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("123"))
+		notifier.Flush()
+
+		for _, path := range []string{
+			"/api/v3/projects/1/notices",
+			"/api/v3/projects/2/notices",
+			"/api/v3/projects/3/notices",
+		} {
+			Expect(received(path)).To(HaveLen(1))
+			Expect(received(path)[0].Errors[0].Message).To(Equal("hello"))
+		}
+	})
+
+	It("applies destination filters only to the destination", func() {
+		_, err := notifier.SendNotice(notifier.Notice("hello", nil, 0))
+		Expect(err).NotTo(HaveOccurred())
+		notifier.Flush()
+
+		Expect(received("/api/v3/projects/1/notices")[0].Context).NotTo(HaveKey("team"))
+		Expect(received("/api/v3/projects/2/notices")[0].Context["team"]).To(Equal("platform"))
+	})
+
+	It("rate limits destinations independently", func() {
+		for i := 0; i < 3; i++ {
+			_, err := notifier.SendNotice(notifier.Notice("hello", nil, 0))
+			Expect(err).NotTo(HaveOccurred())
+			notifier.Flush()
+		}
+
+		Expect(received("/api/v3/projects/1/notices")).To(HaveLen(3))
+		Expect(received("/api/v3/projects/2/notices")).To(HaveLen(3))
+		Expect(received("/api/v3/projects/3/notices")).To(HaveLen(1))
+	})
+
+	It("sends APM stats to every destination", func() {
+		_, metric := gobrake.NewRouteMetric(context.TODO(), "GET", "/ping")
+		metric.StatusCode = http.StatusOK
+		Expect(notifier.Routes.Notify(context.TODO(), metric)).To(Succeed())
+		notifier.Routes.Flush()
+		notifier.Flush()
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(apmRequests).To(HaveKeyWithValue("/api/v5/projects/1/routes-stats", 1))
+		Expect(apmRequests).To(HaveKeyWithValue("/api/v5/projects/2/routes-stats", 1))
+		Expect(apmRequests).To(HaveKeyWithValue("/api/v5/projects/3/routes-stats", 1))
+	})
+
+	Context("when a destination hangs", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			hanging := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, req *http.Request) {
+					<-release
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"id":"456"}`))
+				},
+			))
+			DeferCleanup(hanging.Close)
+
+			opt.Destinations = []gobrake.Destination{{
+				ProjectId:  4,
+				ProjectKey: "key4",
+				Host:       hanging.URL,
+			}}
+		})
+
+		It("delivers notices to the notifier project promptly", func() {
+			defer close(release)
+
+			start := time.Now()
+			id, err := notifier.SendNotice(notifier.Notice("hello", nil, 0))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(id).To(Equal("123"))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(received("/api/v3/projects/1/notices")).To(HaveLen(1))
+		})
+
+		It("sends APM stats to the notifier project promptly", func() {
+			defer close(release)
+
+			_, metric := gobrake.NewRouteMetric(context.TODO(), "GET", "/ping")
+			metric.StatusCode = http.StatusOK
+			Expect(notifier.Routes.Notify(context.TODO(), metric)).To(Succeed())
+
+			start := time.Now()
+			notifier.Routes.Flush()
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(apmRequests).To(HaveKeyWithValue("/api/v5/projects/1/routes-stats", 1))
+		})
+	})
+})
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/airbrake/gobrake/v5"
+	"github.com/airbrake/gobrake/v5/internal/testutil"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
 )
@@ -33,6 +34,37 @@ func Test_Fiberbrake(t *testing.T) {
 	utils.AssertEqual(t, "Hello", string(body))
 }
 
+// go test -run Test_Fiberbrake_KeepsMetric
+func Test_Fiberbrake_KeepsMetric(t *testing.T) {
+	notifier := testutil.NewNotifier(t)
+
+	var metrics []*gobrake.RouteMetric
+	notifier.Routes.AddFilter(func(metric *gobrake.RouteMetric) *gobrake.RouteMetric {
+		metrics = append(metrics, metric)
+		return metric
+	})
+
+	app := fiber.New()
+	app.Use(New(notifier))
+	app.Get("/a", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	app.Get("/b", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/a", "/b"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err)
+	}
+
+	utils.AssertEqual(t, 2, len(metrics))
+	utils.AssertEqual(t, "/a", metrics[0].Route)
+	utils.AssertEqual(t, http.StatusOK, metrics[0].StatusCode)
+	utils.AssertEqual(t, "/b", metrics[1].Route)
+	utils.AssertEqual(t, http.StatusNotFound, metrics[1].StatusCode)
+}
+
 // go test -run Test_Fiberbrake_Next
 func Test_Fiberbrake_Next(t *testing.T) {
 	app := fiber.New()
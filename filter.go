@@ -2,6 +2,7 @@ package gobrake
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -9,8 +10,8 @@ import (
 )
 
 func newNotifierFilter(notifier *Notifier) func(*Notice) *Notice {
-	opt := notifier.opt
 	return func(notice *Notice) *Notice {
+		opt := notifier.opts.Load()
 		if opt.Environment != "" {
 			notice.Context["environment"] = opt.Environment
 		}
@@ -33,6 +34,14 @@ func NewBlocklistKeysFilter(keys ...interface{}) func(*Notice) *Notice {
 	}
 }
 
+// newKeysBlocklistFilter is like NewBlocklistKeysFilter, but uses the
+// current KeysBlocklist that can be updated at runtime.
+func newKeysBlocklistFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		return NewBlocklistKeysFilter(opts.Load().KeysBlocklist...)(notice)
+	}
+}
+
 func filterByKey(values map[string]interface{}, key interface{}) map[string]interface{} {
 	const filtered = "[Filtered]"
 
@@ -56,6 +65,17 @@ func filterByKey(values map[string]interface{}, key interface{}) map[string]inte
 	return values
 }
 
+func modulePathFilter(notice *Notice) *Notice {
+	paths := getModulePaths()
+	for i := range notice.Errors {
+		backtrace := notice.Errors[i].Backtrace
+		for j := range backtrace {
+			paths.normalizeFrame(&backtrace[j])
+		}
+	}
+	return notice
+}
+
 func gopathFilter(notice *Notice) *Notice {
 	s, ok := notice.Context["gopath"].(string)
 	if !ok {
@@ -81,36 +101,38 @@ func gopathFilter(notice *Notice) *Notice {
 	return notice
 }
 
-func gitFilter(notice *Notice) *Notice {
-	rootDir, _ := notice.Context["rootDirectory"].(string)
-	if rootDir == "" {
-		return notice
-	}
+func newGitFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		rootDir, _ := notice.Context["rootDirectory"].(string)
+		if rootDir == "" {
+			return notice
+		}
 
-	gitDir, ok := findGitDir(rootDir)
-	if !ok {
-		return notice
-	}
+		gitDir, ok := findGitDir(rootDir)
+		if !ok {
+			return notice
+		}
 
-	info := getGitInfo(gitDir)
+		info := getGitInfo(gitDir, opts.Load().log())
 
-	if notice.Context == nil {
-		notice.Context = make(map[string]interface{})
-	}
+		if notice.Context == nil {
+			notice.Context = make(map[string]interface{})
+		}
 
-	if notice.Context["repository"] == nil && info.Repository != "" {
-		notice.Context["repository"] = info.Repository
-	}
+		if notice.Context["repository"] == nil && info.Repository != "" {
+			notice.Context["repository"] = info.Repository
+		}
 
-	if notice.Context["revision"] == nil && info.Revision != "" {
-		notice.Context["revision"] = info.Revision
-	}
+		if notice.Context["revision"] == nil && info.Revision != "" {
+			notice.Context["revision"] = info.Revision
+		}
 
-	if info.LastCheckout != nil {
-		notice.Context["lastCheckout"] = info.LastCheckout
-	}
+		if info.LastCheckout != nil {
+			notice.Context["lastCheckout"] = info.LastCheckout
+		}
 
-	return notice
+		return notice
+	}
 }
 
 func httpUnsolicitedResponseFilter(notice *Notice) *Notice {
@@ -126,7 +148,55 @@ func httpUnsolicitedResponseFilter(notice *Notice) *Notice {
 	return nil
 }
 
-func codeHunksFilter(notice *Notice) *Notice {
+// newIgnoredErrorsFilter ignores notices with error types or messages
+// listed in options.
+func newIgnoredErrorsFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		opt := opts.Load()
+		types := opt.IgnoredErrorTypes
+		messages := opt.IgnoredErrorMessages
+
+		for _, e := range notice.Errors {
+			for _, typ := range types {
+				if e.Type == typ {
+					return nil
+				}
+			}
+			for _, msg := range messages {
+				if strings.Contains(e.Message, msg) {
+					return nil
+				}
+			}
+		}
+		return notice
+	}
+}
+
+// newSamplingFilter ignores notices randomly according to SamplingRate.
+func newSamplingFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		rate := opts.Load().SamplingRate
+		if rate <= 0 || rate >= 1 {
+			return notice
+		}
+		if rand.Float64() >= rate {
+			return nil
+		}
+		return notice
+	}
+}
+
+func newCodeHunksFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		opt := opts.Load()
+		if opt.DisableCodeHunks {
+			return notice
+		}
+		return codeHunksFilter(notice, opt.log())
+	}
+}
+
+func codeHunksFilter(notice *Notice, log Logger) *Notice {
 	for i := range notice.Errors {
 		error := &notice.Errors[i]
 		for j := range error.Backtrace {
@@ -134,8 +204,8 @@ func codeHunksFilter(notice *Notice) *Notice {
 			code, err := getCode(frame.File, frame.Line)
 			if err != nil {
 				if !os.IsNotExist(err) {
-					logger.Printf("getCode file=%q line=%d failed: %s",
-						frame.File, frame.Line, err)
+					log.Warn("getCode failed",
+						"file", frame.File, "line", frame.Line, "error", err)
 				}
 				continue
 			}
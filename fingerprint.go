@@ -0,0 +1,66 @@
+package gobrake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+var (
+	quotedRe = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`[^`]*`")
+	uuidRe   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexRe    = regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b|\b[0-9a-f]{6,}\b`)
+	// Numbers that are words on their own, optionally followed by a unit
+	// such as 1.5s, but not digits of words like http2.
+	numberRe = regexp.MustCompile(`\b\d+(?:\.\d+)?([a-zA-Z]*)\b`)
+)
+
+// NormalizeMessage replaces quoted strings, UUIDs, hex and decimal numbers in
+// the error message with placeholders, so messages that differ only by ids
+// are grouped together.
+func NormalizeMessage(msg string) string {
+	msg = quotedRe.ReplaceAllString(msg, "<str>")
+	msg = uuidRe.ReplaceAllString(msg, "<uuid>")
+	msg = hexRe.ReplaceAllStringFunc(msg, func(s string) string {
+		// Words like "deadbeef" and plain numbers are not treated as hex.
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") ||
+			(strings.IndexAny(s, "0123456789") != -1 &&
+				strings.IndexAny(s, "abcdefABCDEF") != -1) {
+			return "<hex>"
+		}
+		return s
+	})
+	msg = numberRe.ReplaceAllString(msg, "<num>$1")
+	return msg
+}
+
+// DefaultFingerprinter returns fingerprint computed from error types,
+// normalized error messages and the first backtrace frame of every error.
+func DefaultFingerprinter(notice *Notice) string {
+	h := sha256.New()
+	for _, e := range notice.Errors {
+		h.Write([]byte(e.Type))
+		h.Write([]byte{0})
+		h.Write([]byte(NormalizeMessage(e.Message)))
+		h.Write([]byte{0})
+		if len(e.Backtrace) > 0 {
+			frame := e.Backtrace[0]
+			h.Write([]byte(frame.File))
+			h.Write([]byte{0})
+			h.Write([]byte(frame.Func))
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newFingerprintFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		fingerprinter := opts.Load().Fingerprinter
+		if fingerprinter != nil && notice.Fingerprint == "" {
+			notice.Fingerprint = fingerprinter(notice)
+		}
+		return notice
+	}
+}
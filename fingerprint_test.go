@@ -0,0 +1,47 @@
+package gobrake_test
+
+import (
+	"github.com/airbrake/gobrake/v5"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NormalizeMessage", func() {
+	It("replaces ids with placeholders", func() {
+		tests := []struct {
+			msg  string
+			want string
+		}{
+			{"user 123 not found", "user <num> not found"},
+			{"took 1.5s", "took <num>s"},
+			{"http2: stream 7 closed", "http2: stream <num> closed"},
+			{"user123 not found in shard_2", "user123 not found in shard_2"},
+			{
+				"order 8d2a3c1e-0b7f-4e59-9b0a-1f2e3d4c5b6a failed",
+				"order <uuid> failed",
+			},
+			{"bad pointer 0xc000123abc", "bad pointer <hex>"},
+			{"commit 4f2a9e1b not found", "commit <hex> not found"},
+			{`key "foo" not found`, "key <str> not found"},
+			{"key 'bar' not found", "key <str> not found"},
+			{"cafe decade", "cafe decade"},
+			{"no ids here", "no ids here"},
+		}
+
+		for _, test := range tests {
+			Expect(gobrake.NormalizeMessage(test.msg)).To(Equal(test.want))
+		}
+	})
+})
+
+var _ = Describe("DefaultFingerprinter", func() {
+	It("returns the same fingerprint for messages that differ by ids", func() {
+		n1 := gobrake.NewNotice("user 1 not found", nil, -1)
+		n2 := gobrake.NewNotice("user 2 not found", nil, -1)
+		n3 := gobrake.NewNotice("order 2 not found", nil, -1)
+
+		Expect(gobrake.DefaultFingerprinter(n1)).To(Equal(gobrake.DefaultFingerprinter(n2)))
+		Expect(gobrake.DefaultFingerprinter(n1)).NotTo(Equal(gobrake.DefaultFingerprinter(n3)))
+	})
+})
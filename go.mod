@@ -3,6 +3,7 @@ module github.com/airbrake/gobrake/v5
 go 1.17
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/apex/log v1.9.0
 	github.com/beego/beego/v2 v2.0.7
 	github.com/buger/jsonparser v1.1.1
@@ -21,10 +22,10 @@ require (
 	github.com/urfave/negroni v1.0.0
 	github.com/valyala/fasthttp v1.43.0
 	go.uber.org/zap v1.24.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/BurntSushi/toml v1.2.1 // indirect
 	github.com/CloudyKit/fastprinter v0.0.0-20200109182630-33d98a066a53 // indirect
 	github.com/CloudyKit/jet/v6 v6.2.0 // indirect
 	github.com/Joker/jade v1.1.3 // indirect
@@ -147,5 +148,4 @@ require (
 	google.golang.org/protobuf v1.28.1 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
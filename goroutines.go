@@ -0,0 +1,216 @@
+package gobrake
+
+import (
+	"bufio"
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultMaxGoroutines = 50
+
+	// How many top frames are kept for every goroutine.
+	maxGoroutineFrames = 8
+
+	// Upper limit for the buffer the goroutine dump is written to.
+	maxGoroutineDumpLen = 64 << 20
+)
+
+// Goroutine describes a goroutine from the goroutine dump.
+type Goroutine struct {
+	ID             int          `json:"id"`
+	State          string       `json:"state"`
+	WaitMinutes    int          `json:"waitMinutes,omitempty"`
+	LockedToThread bool         `json:"lockedToThread,omitempty"`
+	Frames         []StackFrame `json:"frames"`
+	CreatedBy      *StackFrame  `json:"createdBy,omitempty"`
+}
+
+// Goroutines returns parsed dump of all goroutines except the calling one.
+// Only top frames of every goroutine are returned.
+func Goroutines() []Goroutine {
+	goroutines := parseGoroutines(goroutineDump(), maxGoroutineFrames)
+	if len(goroutines) > 0 {
+		// The calling goroutine always comes first.
+		goroutines = goroutines[1:]
+	}
+	return goroutines
+}
+
+func goroutineDump() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineDumpLen {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// parseGoroutines parses output of runtime.Stack and panic tracebacks
+// keeping at most maxFrames top frames of every goroutine.
+func parseGoroutines(dump []byte, maxFrames int) []Goroutine {
+	var goroutines []Goroutine
+	var g *Goroutine
+	var frame *StackFrame
+	var elided bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(dump))
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "goroutine "):
+			goroutines = append(goroutines, parseGoroutineHeader(line))
+			g = &goroutines[len(goroutines)-1]
+			frame = nil
+			elided = false
+		case g == nil || line == "":
+			continue
+		case strings.HasPrefix(line, "\t"):
+			if frame != nil {
+				frame.File, frame.Line = parseFileLine(line)
+				frame = nil
+			}
+		case strings.HasPrefix(line, "created by "):
+			pkg, fn := splitPackageFuncName(parseFuncName(line[len("created by "):]))
+			g.CreatedBy = &StackFrame{Func: fn, pkg: pkg}
+			frame = g.CreatedBy
+		case strings.HasPrefix(line, "..."):
+			elided = true
+		default:
+			if elided || len(g.Frames) >= maxFrames {
+				frame = nil
+				continue
+			}
+			pkg, fn := splitPackageFuncName(parseFuncName(line))
+			g.Frames = append(g.Frames, StackFrame{Func: fn, pkg: pkg})
+			frame = &g.Frames[len(g.Frames)-1]
+		}
+	}
+
+	return goroutines
+}
+
+// parseGoroutineHeader parses lines like
+// "goroutine 7 [chan receive, 5 minutes, locked to thread]:".
+func parseGoroutineHeader(line string) Goroutine {
+	var g Goroutine
+
+	fields := strings.Fields(line)
+	if len(fields) > 1 {
+		g.ID, _ = strconv.Atoi(fields[1])
+	}
+
+	start := strings.IndexByte(line, '[')
+	end := strings.LastIndexByte(line, ']')
+	if start == -1 || end < start {
+		return g
+	}
+	for i, part := range strings.Split(line[start+1:end], ", ") {
+		switch {
+		case i == 0:
+			g.State = part
+		case strings.HasSuffix(part, " minutes"):
+			g.WaitMinutes, _ = strconv.Atoi(strings.TrimSuffix(part, " minutes"))
+		case part == "locked to thread":
+			g.LockedToThread = true
+		}
+	}
+	return g
+}
+
+// parseFuncName strips call arguments and the goroutine of the creator, e.g.
+// "main.(*T).run(0xc000010000)" or "main.start in goroutine 1".
+func parseFuncName(s string) string {
+	if ind := strings.Index(s, " in goroutine "); ind != -1 {
+		s = s[:ind]
+	}
+	if strings.HasSuffix(s, ")") {
+		if ind := strings.LastIndexByte(s, '('); ind > 0 {
+			s = s[:ind]
+		}
+	}
+	return s
+}
+
+// parseFileLine parses lines like "\t/src/main.go:10 +0x1d".
+func parseFileLine(s string) (string, int) {
+	s = strings.TrimSpace(s)
+	if ind := strings.LastIndex(s, " +0x"); ind != -1 {
+		s = s[:ind]
+	}
+	ind := strings.LastIndexByte(s, ':')
+	if ind == -1 {
+		return s, 0
+	}
+	line, err := strconv.Atoi(s[ind+1:])
+	if err != nil {
+		return s, 0
+	}
+	return s[:ind], line
+}
+
+// newGoroutinesFilter attaches the goroutine dump to critical notices when
+// EnableGoroutineDump is set.
+// Goroutines started by gobrake are skipped.
+func newGoroutinesFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		opt := opts.Load()
+		if !opt.EnableGoroutineDump {
+			return notice
+		}
+		if notice.Context["severity"] != "critical" {
+			return notice
+		}
+		// Crash notices carry goroutines of the crashed process.
+		if notice.Context["crash"] == true {
+			return notice
+		}
+		if _, ok := notice.Context["goroutines"]; ok {
+			return notice
+		}
+
+		paths := getModulePaths()
+		all := Goroutines()
+		goroutines := make([]Goroutine, 0, len(all))
+		for _, g := range all {
+			if len(goroutines) == opt.MaxGoroutines {
+				break
+			}
+			if isGobrakeGoroutine(&g) {
+				continue
+			}
+			for i := range g.Frames {
+				paths.normalizeFrame(&g.Frames[i])
+			}
+			if g.CreatedBy != nil {
+				paths.normalizeFrame(g.CreatedBy)
+			}
+			goroutines = append(goroutines, g)
+		}
+
+		notice.Context["goroutines"] = goroutines
+		notice.Context["goroutinesCount"] = len(all) + 1
+		return notice
+	}
+}
+
+func isGobrakeGoroutine(g *Goroutine) bool {
+	return g.CreatedBy != nil && g.CreatedBy.pkg == gobrakeModule
+}
+
+// trimGoroutines halves the goroutine dump attached to the notice. It
+// reports whether the dump was trimmed.
+func trimGoroutines(notice *Notice) bool {
+	goroutines, ok := notice.Context["goroutines"].([]Goroutine)
+	if !ok || len(goroutines) == 0 {
+		return false
+	}
+	notice.Context["goroutines"] = goroutines[:len(goroutines)/2]
+	return true
+}
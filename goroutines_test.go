@@ -0,0 +1,76 @@
+package gobrake
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseGoroutines", func() {
+	It("parses goroutine dump", func() {
+		dump := `goroutine 1 [running]:
+main.main()
+	/src/app/main.go:10 +0x1d
+
+goroutine 7 [chan receive, 5 minutes, locked to thread]:
+net/http.(*Server).Serve(0xc000100000, {0x7a2b40, 0xc000010000})
+	/usr/local/go/src/net/http/server.go:3056 +0x3a5
+main.(*worker).run(...)
+	/src/app/worker.go:42
+created by main.start in goroutine 1
+	/src/app/worker.go:30 +0x65
+
+goroutine 8 [select]:
+...additional frames elided...
+created by github.com/airbrake/gobrake/v5.newRemoteConfig
+	/src/gobrake/remote_config.go:20 +0x65
+`
+
+		goroutines := parseGoroutines([]byte(dump), maxGoroutineFrames)
+		Expect(goroutines).To(HaveLen(3))
+
+		Expect(goroutines[0].ID).To(Equal(1))
+		Expect(goroutines[0].State).To(Equal("running"))
+		Expect(goroutines[0].Frames).To(Equal([]StackFrame{
+			{File: "/src/app/main.go", Line: 10, Func: "main", pkg: "main"},
+		}))
+
+		g := goroutines[1]
+		Expect(g.ID).To(Equal(7))
+		Expect(g.State).To(Equal("chan receive"))
+		Expect(g.WaitMinutes).To(Equal(5))
+		Expect(g.LockedToThread).To(BeTrue())
+		Expect(g.Frames).To(Equal([]StackFrame{
+			{File: "/usr/local/go/src/net/http/server.go", Line: 3056, Func: "(*Server).Serve", pkg: "net/http"},
+			{File: "/src/app/worker.go", Line: 42, Func: "(*worker).run", pkg: "main"},
+		}))
+		Expect(g.CreatedBy).To(Equal(&StackFrame{File: "/src/app/worker.go", Line: 30, Func: "start", pkg: "main"}))
+		Expect(isGobrakeGoroutine(&g)).To(BeFalse())
+
+		Expect(goroutines[2].Frames).To(BeEmpty())
+		Expect(isGobrakeGoroutine(&goroutines[2])).To(BeTrue())
+	})
+
+	It("keeps top frames only", func() {
+		dump := "goroutine 1 [running]:\n"
+		for i := 0; i < 2*maxGoroutineFrames; i++ {
+			dump += "main.f()\n\t/src/main.go:1 +0x1\n"
+		}
+
+		goroutines := parseGoroutines([]byte(dump), maxGoroutineFrames)
+		Expect(goroutines[0].Frames).To(HaveLen(maxGoroutineFrames))
+	})
+})
+
+var _ = Describe("trimGoroutines", func() {
+	It("halves goroutine dump", func() {
+		notice := &Notice{Context: map[string]interface{}{
+			"goroutines": make([]Goroutine, 4),
+		}}
+
+		Expect(trimGoroutines(notice)).To(BeTrue())
+		Expect(notice.Context["goroutines"]).To(HaveLen(2))
+		Expect(trimGoroutines(notice)).To(BeTrue())
+		Expect(trimGoroutines(notice)).To(BeTrue())
+		Expect(trimGoroutines(notice)).To(BeFalse())
+	})
+})
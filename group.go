@@ -0,0 +1,186 @@
+package gobrake
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// PanicError is returned when a goroutine started by Notifier.Go or Group
+// panics and NotifierOptions.SwallowPanics is set.
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Go runs fn in a new goroutine. An error returned by fn is reported to
+// Airbrake. A panic is reported as a critical notice that includes the
+// backtrace of the Go call site and is re-raised unless
+// NotifierOptions.SwallowPanics is set.
+func (n *Notifier) Go(c context.Context, fn func(context.Context) error) {
+	spawn := callerPCs(1)
+	go func() {
+		_ = n.run(c, spawn, func() error {
+			return fn(c)
+		})
+	}()
+}
+
+// run calls fn, reports the returned error or the panic and returns the
+// error.
+func (n *Notifier) run(c context.Context, spawn []uintptr, fn func() error) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+
+		n.notifyGoroutinePanic(c, v, spawn)
+		if !n.opts.Load().SwallowPanics {
+			panic(v)
+		}
+		err = &PanicError{Value: v}
+	}()
+
+	err = fn()
+	if err != nil {
+		notice := n.Notice(err, nil, 1)
+		addContextBreadcrumbs(c, notice)
+		n.SendNoticeAsync(notice)
+	}
+	return err
+}
+
+func (n *Notifier) notifyGoroutinePanic(c context.Context, v interface{}, spawn []uintptr) {
+	notice := n.Notice(v, nil, 3)
+	notice.Context["severity"] = "critical"
+	_, backtrace := backtraceFromPCs(spawn)
+	notice.Errors = append(notice.Errors, Error{
+		Type:      "goroutine",
+		Message:   "goroutine created at",
+		Backtrace: backtrace,
+	})
+	addContextBreadcrumbs(c, notice)
+
+	if _, err := n.SendNotice(notice); err != nil {
+		n.opts.Load().log().Error(
+			"SendNotice failed",
+			"notice", notice, "error", err,
+		)
+	}
+}
+
+func addContextBreadcrumbs(c context.Context, notice *Notice) {
+	if bcs := ContextBreadcrumbs(c).All(); len(bcs) > 0 {
+		notice.Context["breadcrumbs"] = bcs
+	}
+}
+
+// callerPCs returns the program counters of the stack starting skip frames
+// above the caller of callerPCs. They are symbolized only if the goroutine
+// panics.
+func callerPCs(skip int) []uintptr {
+	var pcs [maxCollectedFrames]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	return append([]uintptr(nil), pcs[:n]...)
+}
+
+// Group is a collection of goroutines working on subtasks of the same task.
+// It has the same API as golang.org/x/sync/errgroup.Group, but errors and
+// panics are reported to Airbrake like in Notifier.Go.
+type Group struct {
+	notifier *Notifier
+	ctx      context.Context
+	cancel   func()
+
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup returns a new Group and an associated context derived from c.
+// The derived context is canceled the first time a function passed to Go
+// returns an error or panics, or the first time Wait returns.
+func (n *Notifier) NewGroup(c context.Context) (*Group, context.Context) {
+	c, cancel := context.WithCancel(c)
+	return &Group{
+		notifier: n,
+		ctx:      c,
+		cancel:   cancel,
+	}, c
+}
+
+// Go calls fn in a new goroutine. It blocks until the new goroutine can be
+// added without the number of active goroutines exceeding the limit.
+// The first error returned by fn is returned by Wait.
+func (g *Group) Go(fn func() error) {
+	spawn := callerPCs(1)
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(spawn, fn)
+}
+
+// TryGo calls fn in a new goroutine only if the number of active goroutines
+// is below the limit. It reports whether the goroutine was started.
+func (g *Group) TryGo(fn func() error) bool {
+	spawn := callerPCs(1)
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(spawn, fn)
+	return true
+}
+
+func (g *Group) start(spawn []uintptr, fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+
+		if err := g.notifier.run(g.ctx, spawn, fn); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// SetLimit limits the number of active goroutines in the group to at most
+// n. A negative value indicates no limit. The limit must not be modified
+// while any goroutines in the group are active.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic(fmt.Errorf("gobrake: modify limit while %v goroutines in the group are still active", len(g.sem)))
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Wait blocks until all function calls from the Go method have returned,
+// then returns the first non-nil error (if any) from them.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
@@ -43,11 +43,13 @@ func (h *Handler) HandleFunc(handler http.HandlerFunc) http.HandlerFunc {
 func (h *Handler) handle(handler http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		ctx, routeMetric := gobrake.NewRouteMetric(ctx, r.Method, r.URL.Path) // Starts the timing
+		ctx, routeMetric := gobrake.NewRouteMetric(ctx, r.Method, requestRoute(r)) // Starts the timing
 		arw := newAirbrakeResponseWriter(w)
 
 		handler.ServeHTTP(arw, r)
 
+		// http.ServeMux sets the pattern of the request when it routes it.
+		routeMetric.Route = requestRoute(r)
 		routeMetric.StatusCode = arw.statusCode
 		_ = h.Notifier.Routes.Notify(ctx, routeMetric)
 	}
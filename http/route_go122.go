@@ -0,0 +1,13 @@
+//go:build !go1.23
+// +build !go1.23
+
+package http
+
+import (
+	"net/http"
+)
+
+// requestRoute returns the URL path of the request.
+func requestRoute(r *http.Request) string {
+	return r.URL.Path
+}
@@ -0,0 +1,20 @@
+//go:build go1.23
+// +build go1.23
+
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+// requestRoute returns the path of the http.ServeMux pattern that matched
+// the request, e.g. /users/{id}, or the URL path if the request wasn't
+// routed by http.ServeMux.
+func requestRoute(r *http.Request) string {
+	// Patterns are [METHOD ][HOST]/[PATH].
+	if i := strings.IndexByte(r.Pattern, '/'); i != -1 {
+		return r.Pattern[i:]
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,28 @@
+//go:build go1.23
+// +build go1.23
+
+// The module's go version selects the Go 1.21 ServeMux by default.
+//go:debug httpmuxgo121=0
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestRoute(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	r := httptest.NewRequest("GET", "/users/123", nil)
+	if got := requestRoute(r); got != "/users/123" {
+		t.Fatalf("got %q, wanted /users/123 before routing", got)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	if got := requestRoute(r); got != "/users/{id}" {
+		t.Fatalf("got %q, wanted /users/{id}", got)
+	}
+}
@@ -0,0 +1,31 @@
+// Package testutil contains helpers shared by tests of gobrake integrations.
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/airbrake/gobrake/v5"
+)
+
+// NewNotifier returns a notifier that sends notices to a test server. The
+// notifier and the server are closed when the test finishes.
+func NewNotifier(t testing.TB) *gobrake.Notifier {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"123"}`))
+		},
+	))
+	t.Cleanup(server.Close)
+
+	notifier := gobrake.NewNotifierWithOptions(&gobrake.NotifierOptions{
+		ProjectId:           1,
+		ProjectKey:          "key",
+		Host:                server.URL,
+		DisableRemoteConfig: true,
+	})
+	t.Cleanup(func() { _ = notifier.Close() })
+	return notifier
+}
@@ -0,0 +1,127 @@
+package gobrake
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger is a leveled structured logger used by the notifier. keyvals are
+// alternating keys and values, e.g. "project_id", 1, "error", err.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LogLevel(%d)", int(l))
+	}
+}
+
+var (
+	// Logs to the logger set with SetLogger.
+	defaultLogger Logger = &stdLogger{min: LogLevelInfo}
+	debugLogger   Logger = &stdLogger{min: LogLevelDebug}
+)
+
+// NewStdLogger returns Logger that writes messages of level min and higher
+// to l as "[LEVEL] msg key=value ...".
+func NewStdLogger(l *log.Logger, min LogLevel) Logger {
+	return &stdLogger{
+		l:   l,
+		min: min,
+	}
+}
+
+type stdLogger struct {
+	// nil means the logger set with SetLogger.
+	l   *log.Logger
+	min LogLevel
+}
+
+func (l *stdLogger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LogLevelDebug, msg, keyvals)
+}
+
+func (l *stdLogger) Info(msg string, keyvals ...interface{}) {
+	l.log(LogLevelInfo, msg, keyvals)
+}
+
+func (l *stdLogger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LogLevelWarn, msg, keyvals)
+}
+
+func (l *stdLogger) Error(msg string, keyvals ...interface{}) {
+	l.log(LogLevelError, msg, keyvals)
+}
+
+func (l *stdLogger) log(level LogLevel, msg string, keyvals []interface{}) {
+	if level < l.min {
+		return
+	}
+
+	out := l.l
+	if out == nil {
+		out = logger
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[")
+	sb.WriteString(level.String())
+	sb.WriteString("] ")
+	sb.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		sb.WriteString(" ")
+		fmt.Fprint(&sb, keyvals[i])
+		sb.WriteString("=")
+		if i+1 < len(keyvals) {
+			sb.WriteString(formatLogValue(keyvals[i+1]))
+		} else {
+			sb.WriteString(`"MISSING"`)
+		}
+	}
+
+	// Skip log, Debug/Info/Warn/Error and report the caller.
+	_ = out.Output(3, sb.String())
+}
+
+func formatLogValue(v interface{}) string {
+	switch v := v.(type) {
+	case string, error, fmt.Stringer:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// log returns the logger of the notifier.
+func (opt *NotifierOptions) log() Logger {
+	if opt.Logger != nil {
+		return opt.Logger
+	}
+	if opt.Debug {
+		return debugLogger
+	}
+	return defaultLogger
+}
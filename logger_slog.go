@@ -0,0 +1,34 @@
+//go:build go1.21
+// +build go1.21
+
+package gobrake
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogLogger returns Logger that writes notifier messages to l.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return &slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (l *slogLogger) Debug(msg string, keyvals ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelDebug, msg, keyvals...)
+}
+
+func (l *slogLogger) Info(msg string, keyvals ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelInfo, msg, keyvals...)
+}
+
+func (l *slogLogger) Warn(msg string, keyvals ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelWarn, msg, keyvals...)
+}
+
+func (l *slogLogger) Error(msg string, keyvals ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelError, msg, keyvals...)
+}
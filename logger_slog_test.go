@@ -0,0 +1,28 @@
+//go:build go1.21
+// +build go1.21
+
+package gobrake_test
+
+import (
+	"bytes"
+	"log/slog"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/airbrake/gobrake/v5"
+)
+
+var _ = Describe("NewSlogLogger", func() {
+	It("writes messages with levels and attributes", func() {
+		buf := new(bytes.Buffer)
+		h := slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+		l := gobrake.NewSlogLogger(slog.New(h))
+
+		l.Debug("skipped")
+		l.Error("sendNotice failed", "project_id", 1)
+
+		Expect(buf.String()).NotTo(ContainSubstring("skipped"))
+		Expect(buf.String()).To(ContainSubstring(`level=ERROR msg="sendNotice failed" project_id=1`))
+	})
+})
@@ -0,0 +1,146 @@
+package gobrake_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/airbrake/gobrake/v5"
+)
+
+type logEntry struct {
+	level   string
+	msg     string
+	keyvals []interface{}
+}
+
+type testLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *testLogger) add(level, msg string, keyvals []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level, msg, keyvals})
+}
+
+func (l *testLogger) Debug(msg string, keyvals ...interface{}) { l.add("debug", msg, keyvals) }
+func (l *testLogger) Info(msg string, keyvals ...interface{})  { l.add("info", msg, keyvals) }
+func (l *testLogger) Warn(msg string, keyvals ...interface{})  { l.add("warn", msg, keyvals) }
+func (l *testLogger) Error(msg string, keyvals ...interface{}) { l.add("error", msg, keyvals) }
+
+func (l *testLogger) messages(level string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var msgs []string
+	for _, e := range l.entries {
+		if e.level == level {
+			msgs = append(msgs, e.msg)
+		}
+	}
+	return msgs
+}
+
+func (l *testLogger) entriesAt(level string) []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entries []logEntry
+	for _, e := range l.entries {
+		if e.level == level {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+var _ = Describe("NotifierOptions.Logger", func() {
+	var notifier *gobrake.Notifier
+	var opt *gobrake.NotifierOptions
+	var l *testLogger
+
+	BeforeEach(func() {
+		l = new(testLogger)
+
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		server := httptest.NewServer(http.HandlerFunc(handler))
+
+		opt = &gobrake.NotifierOptions{
+			ProjectId:           1,
+			ProjectKey:          "key",
+			Host:                server.URL,
+			DisableRemoteConfig: true,
+			Logger:              l,
+		}
+	})
+
+	JustBeforeEach(func() {
+		notifier = gobrake.NewNotifierWithOptions(opt)
+	})
+
+	AfterEach(func() {
+		Expect(notifier.Close()).NotTo(HaveOccurred())
+	})
+
+	It("receives errors of the notifier", func() {
+		notifier.Notify(errors.New("oops"), nil)
+		notifier.Flush()
+
+		Expect(l.messages("error")).To(Equal([]string{"sendNotice failed"}))
+		Expect(l.entriesAt("error")[0].keyvals).To(ContainElement(MatchError("gobrake: unauthorized: invalid project id or key")))
+	})
+
+	It("doesn't receive payload summaries", func() {
+		notifier.Notify(errors.New("oops"), nil)
+		notifier.Flush()
+
+		Expect(l.messages("debug")).To(BeEmpty())
+	})
+
+	It("receives warnings about spans finished more than once", func() {
+		c, metric := gobrake.NewRouteMetric(context.Background(), "GET", "/")
+		_, span := metric.Start(c, "sql")
+		span.Finish()
+		span.Finish()
+
+		Expect(notifier.Routes.Notify(c, metric)).To(Succeed())
+		Expect(l.messages("warn")).To(Equal([]string{"span is already finished"}))
+		Expect(l.entries[0].keyvals).To(Equal([]interface{}{"span", "sql"}))
+	})
+
+	Context("when Debug is set", func() {
+		BeforeEach(func() {
+			opt.Debug = true
+		})
+
+		It("receives payload summaries", func() {
+			notifier.Notify(errors.New("oops"), nil)
+			notifier.Flush()
+
+			Expect(l.messages("debug")).To(Equal([]string{"sending notice"}))
+		})
+	})
+})
+
+var _ = Describe("NewStdLogger", func() {
+	It("writes messages of enabled levels with key-values", func() {
+		buf := new(bytes.Buffer)
+		l := gobrake.NewStdLogger(log.New(buf, "", 0), gobrake.LogLevelWarn)
+
+		l.Info("skipped")
+		l.Warn("backlog notice failed", "error", errors.New("timeout"), "count", 2)
+
+		Expect(buf.String()).To(Equal(
+			"[WARN] backlog notice failed error=\"timeout\" count=2\n",
+		))
+	})
+})
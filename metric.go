@@ -18,32 +18,75 @@ type Metric interface {
 	Start(c context.Context, name string) (context.Context, Span)
 }
 
-func withMetric(c context.Context, t Metric) context.Context {
-	c = context.WithValue(c, metricCtxKey, t)
-
-	var mu sync.Mutex
-	var span Span
-	var finished bool
-	clientTrace := &httptrace.ClientTrace{
-		GetConn: func(hostPort string) {
-			mu.Lock()
-			defer mu.Unlock()
-			if span == nil {
-				_, span = t.Start(c, "http.client")
-			}
-		},
-		GotFirstResponseByte: func() {
-			mu.Lock()
-			defer mu.Unlock()
-			if !finished {
-				span.Finish()
-				finished = true
-			}
+// withMetric returns a context that carries the metric t and its root span
+// sp. HTTP requests made with the context are measured as http.client spans.
+func withMetric(c context.Context, t Metric, base *metric, sp *span) context.Context {
+	mc := &metricContext{
+		Context: c,
+		metric:  t,
+		base:    base,
+		gen:     atomic.LoadUint32(&base.gen),
+		span:    sp,
+	}
+	return httptrace.WithClientTrace(mc, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			mc.getConn()
 		},
+		GotFirstResponseByte: mc.gotFirstResponseByte,
+	})
+}
+
+// metricContext carries a metric and its root span in a single context
+// instead of a chain of context.WithValue.
+type metricContext struct {
+	context.Context
+
+	metric Metric
+	base   *metric
+	// gen of the base metric when the context was created.
+	gen  uint32
+	span *span
+
+	traceMu       sync.Mutex
+	traceSpan     Span
+	traceFinished bool
+}
+
+func (c *metricContext) Value(key interface{}) interface{} {
+	switch key {
+	case metricCtxKey:
+		if !c.released() {
+			return c.metric
+		}
+	case spanCtxKey:
+		if !c.released() && c.span != nil {
+			return c.span
+		}
 	}
-	c = httptrace.WithClientTrace(c, clientTrace)
+	return c.Context.Value(key)
+}
+
+// released reports whether the metric was returned to the pool after the
+// context was created.
+func (c *metricContext) released() bool {
+	return atomic.LoadUint32(&c.base.gen) != c.gen
+}
 
-	return c
+func (c *metricContext) getConn() {
+	c.traceMu.Lock()
+	defer c.traceMu.Unlock()
+	if c.traceSpan == nil {
+		c.traceSpan = c.base.startGen(c.Context, c.gen, "http.client")
+	}
+}
+
+func (c *metricContext) gotFirstResponseByte() {
+	c.traceMu.Lock()
+	defer c.traceMu.Unlock()
+	if c.traceSpan != nil && !c.traceFinished {
+		c.traceSpan.Finish()
+		c.traceFinished = true
+	}
 }
 
 func ContextMetric(c context.Context) Metric {
@@ -82,8 +125,16 @@ type metric struct {
 	startTime time.Time
 	endTime   time.Time
 
+	// gen is incremented when the metric is returned to the pool. Spans and
+	// contexts of older generations are ignored.
+	gen uint32 // atomic
+
+	// groupsMu also serializes span writes with changes of gen.
 	groupsMu sync.Mutex
 	groups   map[string]time.Duration
+	// Name of the first span finished more than once. It is logged by the
+	// notifier the metric is passed to.
+	refinished string
 }
 
 var _ Metric = (*metric)(nil)
@@ -97,17 +148,54 @@ func (t *metric) Start(c context.Context, name string) (context.Context, Span) {
 		return c, noopSpan{}
 	}
 
-	parent, ok := ContextSpan(c).(*span)
-	if ok {
-		parent.pause()
-	}
 	sp := newSpan(t, name)
-	sp.parent = parent
+	t.startSpan(c, sp)
 
 	c = context.WithValue(c, spanCtxKey, sp)
 	return c, sp
 }
 
+// startGen is like Start, but the span belongs to the generation gen of the
+// metric and is a no-op if the metric was released since.
+func (t *metric) startGen(c context.Context, gen uint32, name string) Span {
+	if atomic.LoadUint32(&t.gen) != gen {
+		return noopSpan{}
+	}
+	sp := newSpan(t, name)
+	sp.gen = gen
+	t.startSpan(c, sp)
+	return sp
+}
+
+// startSpan pauses the current span of c and makes it the parent of sp.
+// sp belongs to the generation of its parent.
+func (t *metric) startSpan(c context.Context, sp *span) {
+	if c == nil {
+		return
+	}
+	parent, ok := ContextSpan(c).(*span)
+	if !ok || parent.metric == nil {
+		return
+	}
+	// Spans started concurrently share the parent.
+	parent.metric.groupsMu.Lock()
+	parent.pause()
+	parent.metric.groupsMu.Unlock()
+	sp.gen = parent.gen
+	sp.parent = parent
+}
+
+// startRoot starts the root span sp and returns a context that carries the
+// metric m and sp.
+func (t *metric) startRoot(c context.Context, m Metric, sp *span, name string) context.Context {
+	sp.init(t, name)
+	t.startSpan(c, sp)
+	if c == nil {
+		return nil
+	}
+	return withMetric(c, m, t, sp)
+}
+
 func (t *metric) finish() {
 	if t.endTime.IsZero() {
 		t.endTime = clock.Now()
@@ -133,17 +221,23 @@ func (t *metric) WithSpan(ctx context.Context, name string, body func(context.Co
 	return nil
 }
 
+// incGroup adds dur to the group. groupsMu must be held.
 func (t *metric) incGroup(name string, dur time.Duration) {
 	if !t.endTime.IsZero() {
 		return
 	}
 
-	t.groupsMu.Lock()
 	if t.groups == nil {
 		t.groups = make(map[string]time.Duration)
 	}
 	t.groups[name] += dur
-	t.groupsMu.Unlock()
+}
+
+// refinishedSpan returns the name of the first span finished more than once.
+func (t *metric) refinishedSpan() string {
+	t.groupsMu.Lock()
+	defer t.groupsMu.Unlock()
+	return t.refinished
 }
 
 func (t *metric) flushGroups() map[string]time.Duration {
@@ -173,39 +267,68 @@ func (noopSpan) Finish() {}
 type span struct {
 	metric *metric
 	parent *span
+	// gen of the metric when the span was started.
+	gen uint32
 
 	name  string
 	start time.Time
 	dur   time.Duration
 
 	paused int32 // atomic
+	// finished is guarded by metric.groupsMu.
+	finished bool
 }
 
 var _ Span = (*span)(nil)
 
 func newSpan(metric *metric, name string) *span {
-	return &span{
+	s := new(span)
+	s.init(metric, name)
+	return s
+}
+
+func (s *span) init(metric *metric, name string) {
+	*s = span{
 		metric: metric,
+		gen:    atomic.LoadUint32(&metric.gen),
 		name:   name,
 		start:  clock.Now(),
 	}
 }
 
 func (s *span) Finish() {
-	if s.metric == nil {
-		logger.Printf("gobrake: span=%q is already finished", s.name)
+	m := s.metric
+	if m == nil {
+		return
+	}
+
+	m.groupsMu.Lock()
+	defer m.groupsMu.Unlock()
+
+	if atomic.LoadUint32(&m.gen) != s.gen {
+		// The metric was returned to the pool.
+		return
+	}
+	if s.finished {
+		if m.refinished == "" {
+			m.refinished = s.name
+		}
 		return
 	}
 	if !s.pause() {
 		return
 	}
 
-	s.metric.incGroup(s.name, s.dur)
-	if s.parent != nil {
-		s.parent.resume()
+	m.incGroup(s.name, s.dur)
+	if p := s.parent; p != nil {
+		if p.metric != m {
+			p.metric.groupsMu.Lock()
+			defer p.metric.groupsMu.Unlock()
+		}
+		p.resume()
 	}
 
-	s.metric = nil
+	s.finished = true
 	s.parent = nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
 	"sync"
 	"time"
 
@@ -218,6 +219,48 @@ var _ = Describe("RouteMetric", func() {
 		Expect(metric.groups["sp0"]).To(BeNumerically("==", 1*time.Millisecond))
 		Expect(metric.duration()).To(BeNumerically("==", 2*time.Millisecond))
 	})
+	It("ignores context and spans of released metric", func() {
+		c, metric := NewRouteMetric(context.Background(), "GET", "/old")
+		_, sp0 := metric.Start(c, "sp0")
+		metric.finish()
+		metric.Release()
+
+		Expect(ContextRouteMetric(c)).To(BeNil())
+		Expect(ContextSpan(c)).To(Equal(noopSpan{}))
+
+		c2, metric2 := NewRouteMetric(context.Background(), "GET", "/new")
+		fakeClock.Advance(time.Millisecond)
+		sp0.Finish()
+
+		Expect(ContextRouteMetric(c2)).To(Equal(metric2))
+		Expect(metric2.groups).NotTo(HaveKey("sp0"))
+	})
+
+	It("ignores HTTP requests made with the context of released metric", func() {
+		c, metric := NewRouteMetric(context.Background(), "GET", "/old")
+		trace := httptrace.ContextClientTrace(c)
+		metric.finish()
+		metric.Release()
+
+		trace.GetConn("example.com:80")
+		fakeClock.Advance(time.Millisecond)
+		trace.GotFirstResponseByte()
+
+		Expect(metric.groups).NotTo(HaveKey("http.client"))
+	})
+
+	It("ignores spans started from a span of released metric", func() {
+		c, metric := NewRouteMetric(context.Background(), "GET", "/old")
+		c, _ = metric.Start(c, "sp0")
+		metric.finish()
+		metric.Release()
+
+		_, sp1 := metric.Start(c, "sp1")
+		fakeClock.Advance(time.Millisecond)
+		sp1.Finish()
+
+		Expect(metric.groups).NotTo(HaveKey("sp1"))
+	})
 })
 
 var _ = Describe("QueueMetric", func() {
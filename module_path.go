@@ -0,0 +1,280 @@
+package gobrake
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// modulePaths describes where source files of the application and its
+// dependencies are located.
+type modulePaths struct {
+	modCache    string
+	projectRoot string
+	modulePath  string
+	// Versions of the dependencies by module path. They are used to find
+	// modules of vendored files.
+	deps map[string]string
+
+	// Project root on the machine that built the binary. It is found from
+	// frames of main module packages, see learnBuildRoot.
+	buildRootMu sync.RWMutex
+	buildRoot   string
+}
+
+var (
+	modulePathsOnce sync.Once
+	_modulePaths    *modulePaths
+)
+
+func getModulePaths() *modulePaths {
+	modulePathsOnce.Do(func() {
+		_modulePaths = newModulePaths()
+	})
+	return _modulePaths
+}
+
+func newModulePaths() *modulePaths {
+	p := &modulePaths{
+		modCache: gomodcache(),
+	}
+
+	// The main module path is stamped into the binary, so it is known on
+	// machines without the source tree. The working directory is only the
+	// project root when it is the source tree of the same module.
+	if info := getBuildInfo(); info != nil {
+		p.modulePath = info.Path
+		p.deps = info.Deps
+	}
+	if wd, err := os.Getwd(); err == nil {
+		root, modulePath := findModuleRoot(wd)
+		if p.modulePath == "" {
+			p.modulePath = modulePath
+		}
+		if modulePath == p.modulePath {
+			p.projectRoot = root
+		}
+	}
+
+	return p
+}
+
+// Returns the module cache directory of the application.
+func gomodcache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	dirs := filepath.SplitList(gopath())
+	if len(dirs) == 0 || dirs[0] == "" {
+		return ""
+	}
+	return filepath.Join(dirs[0], "pkg", "mod")
+}
+
+// findModuleRoot returns the directory containing go.mod and the module path
+// declared in it checking the dir and parent dirs.
+func findModuleRoot(dir string) (string, string) {
+	for i := 0; i < 10; i++ {
+		if path, ok := readModulePath(filepath.Join(dir, "go.mod")); ok {
+			return dir, path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+	return "", ""
+}
+
+func readModulePath(goMod string) (string, bool) {
+	fd, err := os.Open(goMod)
+	if err != nil {
+		return "", false
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module") {
+			path := strings.TrimSpace(line[len("module"):])
+			return strings.Trim(path, `"`), true
+		}
+	}
+	return "", true
+}
+
+// normalizeFrame rewrites frame file paths in the module cache to
+// /GOMODCACHE/... and in the project to /PROJECT_ROOT/... and sets frame
+// module and version. The module cache is recognized by the
+// /pkg/mod/module@version/ path segment and the project root by the main
+// module path, so paths of the machine that built the binary are rewritten
+// too. Files in the vendor directory belong to the vendored module.
+// Relative paths produced by -trimpath builds are handled too.
+func (p *modulePaths) normalizeFrame(frame *StackFrame) {
+	file := frame.File
+
+	if rel, ok := p.modCacheRel(file); ok {
+		frame.File = "/GOMODCACHE/" + rel
+		frame.Module, frame.Version = splitModuleVersion(rel)
+		return
+	}
+
+	p.learnBuildRoot(frame)
+	for _, root := range []string{p.projectRoot, p.getBuildRoot()} {
+		if root == "" {
+			continue
+		}
+		if rel, ok := trimDir(file, root); ok {
+			frame.File = "/PROJECT_ROOT/" + rel
+			if strings.HasPrefix(rel, "vendor/") {
+				frame.Module, frame.Version = p.depModule(rel[len("vendor/"):])
+				return
+			}
+			frame.Module = p.modulePath
+			return
+		}
+	}
+
+	if filepath.IsAbs(file) || strings.HasPrefix(file, "/") {
+		return
+	}
+
+	// -trimpath: dependencies are module@version/file and the main
+	// module is module/file.
+	if module, version := splitModuleVersion(file); module != "" {
+		frame.File = "/GOMODCACHE/" + file
+		frame.Module, frame.Version = module, version
+		return
+	}
+	if p.modulePath != "" && strings.HasPrefix(file, p.modulePath+"/") {
+		frame.File = "/PROJECT_ROOT/" + file[len(p.modulePath)+1:]
+		frame.Module = p.modulePath
+		return
+	}
+	// Vendored dependencies are module/file.
+	frame.Module, frame.Version = p.depModule(file)
+}
+
+// modCacheRel returns file path relative to the module cache. It is found
+// by the runtime module cache dir or by the last /pkg/mod/ path segment that
+// is followed by module@version.
+func (p *modulePaths) modCacheRel(file string) (string, bool) {
+	if p.modCache != "" {
+		if rel, ok := trimDir(file, p.modCache); ok {
+			return rel, true
+		}
+	}
+
+	file = filepath.ToSlash(file)
+	const seg = "/pkg/mod/"
+	ind := strings.LastIndex(file, seg)
+	if ind == -1 {
+		return "", false
+	}
+	rel := file[ind+len(seg):]
+	if module, _ := splitModuleVersion(rel); module == "" {
+		return "", false
+	}
+	return rel, true
+}
+
+// learnBuildRoot finds the project root of the build machine from a frame of
+// a main module package. The package github.com/foo/app/internal/x with the
+// file /ci/app/internal/x/x.go gives /ci/app.
+func (p *modulePaths) learnBuildRoot(frame *StackFrame) {
+	if p.modulePath == "" || p.getBuildRoot() != "" || !path.IsAbs(frame.File) {
+		return
+	}
+	pkg := strings.TrimSuffix(frame.pkg, "_test")
+	if !hasPathPrefix(pkg, p.modulePath) {
+		return
+	}
+
+	dir := path.Dir(frame.File)
+	suffix := pkg[len(p.modulePath):]
+	if !strings.HasSuffix(dir, suffix) {
+		return
+	}
+	root := dir[:len(dir)-len(suffix)]
+	if root == "" || root == "/" {
+		return
+	}
+
+	p.buildRootMu.Lock()
+	p.buildRoot = root
+	p.buildRootMu.Unlock()
+}
+
+func (p *modulePaths) getBuildRoot() string {
+	p.buildRootMu.RLock()
+	defer p.buildRootMu.RUnlock()
+	return p.buildRoot
+}
+
+// depModule returns the dependency with the longest module path that
+// contains file.
+func (p *modulePaths) depModule(file string) (string, string) {
+	var module, version string
+	for mod, v := range p.deps {
+		if len(mod) > len(module) && strings.HasPrefix(file, mod+"/") {
+			module, version = mod, v
+		}
+	}
+	return module, version
+}
+
+// trimDir returns file path relative to dir using forward slashes.
+func trimDir(file, dir string) (string, bool) {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	file = filepath.ToSlash(file)
+	if !strings.HasPrefix(file, dir+"/") {
+		return "", false
+	}
+	return file[len(dir)+1:], true
+}
+
+// splitModuleVersion parses module path and version from a module cache
+// path such as github.com/!burnt!sushi/toml@v1.2.1/decode.go.
+func splitModuleVersion(path string) (string, string) {
+	at := strings.Index(path, "@")
+	if at == -1 {
+		return "", ""
+	}
+	module := path[:at]
+	version := path[at+1:]
+	if ind := strings.IndexByte(version, '/'); ind != -1 {
+		version = version[:ind]
+	}
+	return unescapeModulePath(module), version
+}
+
+// unescapeModulePath reverses the module cache case encoding where upper
+// case letters are stored as '!' followed by the lower case letter.
+func unescapeModulePath(path string) string {
+	if !strings.Contains(path, "!") {
+		return path
+	}
+
+	var b strings.Builder
+	b.Grow(len(path))
+	upper := false
+	for _, r := range path {
+		if r == '!' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
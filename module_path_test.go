@@ -0,0 +1,143 @@
+package gobrake
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("modulePaths.normalizeFrame", func() {
+	paths := &modulePaths{
+		modCache:    "/home/runner/go/pkg/mod",
+		projectRoot: "/home/runner/work/app",
+		modulePath:  "github.com/foo/app",
+		deps: map[string]string{
+			"github.com/foo/bar":    "v1.2.3",
+			"github.com/foo/bar/v2": "v2.0.1",
+		},
+	}
+
+	It("rewrites frames", func() {
+		tests := []struct {
+			file    string
+			want    string
+			module  string
+			version string
+		}{{
+			file:    "/home/runner/go/pkg/mod/github.com/foo/bar@v1.2.3/x.go",
+			want:    "/GOMODCACHE/github.com/foo/bar@v1.2.3/x.go",
+			module:  "github.com/foo/bar",
+			version: "v1.2.3",
+		}, {
+			file:    "/home/runner/go/pkg/mod/github.com/!burnt!sushi/toml@v1.2.1/decode.go",
+			want:    "/GOMODCACHE/github.com/!burnt!sushi/toml@v1.2.1/decode.go",
+			module:  "github.com/BurntSushi/toml",
+			version: "v1.2.1",
+		}, {
+			file:   "/home/runner/work/app/internal/x.go",
+			want:   "/PROJECT_ROOT/internal/x.go",
+			module: "github.com/foo/app",
+		}, {
+			file:    "github.com/foo/bar@v1.2.3/x.go",
+			want:    "/GOMODCACHE/github.com/foo/bar@v1.2.3/x.go",
+			module:  "github.com/foo/bar",
+			version: "v1.2.3",
+		}, {
+			file:   "github.com/foo/app/internal/x.go",
+			want:   "/PROJECT_ROOT/internal/x.go",
+			module: "github.com/foo/app",
+		}, {
+			file:    "/home/runner/work/app/vendor/github.com/foo/bar/x.go",
+			want:    "/PROJECT_ROOT/vendor/github.com/foo/bar/x.go",
+			module:  "github.com/foo/bar",
+			version: "v1.2.3",
+		}, {
+			file:    "/home/runner/work/app/vendor/github.com/foo/bar/v2/pkg/x.go",
+			want:    "/PROJECT_ROOT/vendor/github.com/foo/bar/v2/pkg/x.go",
+			module:  "github.com/foo/bar/v2",
+			version: "v2.0.1",
+		}, {
+			file: "/home/runner/work/app/vendor/github.com/other/lib/x.go",
+			want: "/PROJECT_ROOT/vendor/github.com/other/lib/x.go",
+		}, {
+			file:    "github.com/foo/bar/x.go",
+			want:    "github.com/foo/bar/x.go",
+			module:  "github.com/foo/bar",
+			version: "v1.2.3",
+		}, {
+			file: "runtime/panic.go",
+			want: "runtime/panic.go",
+		}, {
+			file: "/usr/local/go/src/net/http/server.go",
+			want: "/usr/local/go/src/net/http/server.go",
+		}, {
+			file: "/home/runner/work/application/x.go",
+			want: "/home/runner/work/application/x.go",
+		}}
+
+		for _, test := range tests {
+			frame := StackFrame{File: test.file}
+			paths.normalizeFrame(&frame)
+
+			Expect(frame.File).To(Equal(test.want))
+			Expect(frame.Module).To(Equal(test.module))
+			Expect(frame.Version).To(Equal(test.version))
+		}
+	})
+})
+
+var _ = Describe("modulePaths.normalizeFrame on another machine", func() {
+	var paths *modulePaths
+
+	BeforeEach(func() {
+		paths = &modulePaths{
+			modCache:   "/root/go/pkg/mod",
+			modulePath: "github.com/foo/app",
+		}
+	})
+
+	It("rewrites module cache paths of the build machine", func() {
+		frame := StackFrame{File: "/home/runner/go/pkg/mod/github.com/foo/bar@v1.2.3/x.go"}
+		paths.normalizeFrame(&frame)
+
+		Expect(frame.File).To(Equal("/GOMODCACHE/github.com/foo/bar@v1.2.3/x.go"))
+		Expect(frame.Module).To(Equal("github.com/foo/bar"))
+		Expect(frame.Version).To(Equal("v1.2.3"))
+	})
+
+	It("finds the project root of the build machine from main module frames", func() {
+		main := StackFrame{File: "/ci/checkout/cmd/app/main.go", pkg: "main"}
+		paths.normalizeFrame(&main)
+		Expect(main.File).To(Equal("/ci/checkout/cmd/app/main.go"))
+
+		frame := StackFrame{
+			File: "/ci/checkout/internal/x/x.go",
+			pkg:  "github.com/foo/app/internal/x",
+		}
+		paths.normalizeFrame(&frame)
+		Expect(frame.File).To(Equal("/PROJECT_ROOT/internal/x/x.go"))
+		Expect(frame.Module).To(Equal("github.com/foo/app"))
+
+		main = StackFrame{File: "/ci/checkout/cmd/app/main.go", pkg: "main"}
+		paths.normalizeFrame(&main)
+		Expect(main.File).To(Equal("/PROJECT_ROOT/cmd/app/main.go"))
+		Expect(main.Module).To(Equal("github.com/foo/app"))
+	})
+
+	It("ignores frames that don't match their package path", func() {
+		frame := StackFrame{
+			File: "/ci/checkout/other/x.go",
+			pkg:  "github.com/foo/app/internal/x",
+		}
+		paths.normalizeFrame(&frame)
+		Expect(frame.File).To(Equal("/ci/checkout/other/x.go"))
+		Expect(paths.getBuildRoot()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("findModuleRoot", func() {
+	It("returns the module root and path", func() {
+		dir, path := findModuleRoot(getModulePaths().projectRoot + "/internal/testpkg1")
+		Expect(dir).To(Equal(getModulePaths().projectRoot))
+		Expect(path).To(Equal("github.com/airbrake/gobrake/v5"))
+	})
+})
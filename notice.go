@@ -40,6 +40,13 @@ func getDefaultContext() map[string]interface{} {
 		if s := gopath(); s != "" {
 			defaultContext["gopath"] = s
 		}
+
+		if info := getBuildInfo(); info != nil {
+			defaultContext["buildInfo"] = info.context()
+			if v := info.mainVersion(); v != "" {
+				defaultContext["version"] = v
+			}
+		}
 	})
 	return defaultContext
 }
@@ -57,6 +64,17 @@ type StackFrame struct {
 	Line int            `json:"line"`
 	Func string         `json:"function"`
 	Code map[int]string `json:"code,omitempty"`
+
+	// Module path and version the frame belongs to, if known.
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// InApp is true for frames from the application main module.
+	InApp bool `json:"inApp,omitempty"`
+	// Origin is one of app, stdlib, dependency or gobrake.
+	Origin string `json:"origin,omitempty"`
+
+	pkg string
 }
 
 type Error struct {
@@ -69,6 +87,10 @@ type Notice struct {
 	Id    string `json:"-"` // id returned by SendNotice
 	Error error  `json:"-"` // error returned by SendNotice
 
+	// Fingerprint overrides the default Airbrake grouping. Notices with
+	// the same fingerprint are grouped together.
+	Fingerprint string `json:"fingerprint,omitempty"`
+
 	Errors  []Error                `json:"errors"`
 	Context map[string]interface{} `json:"context"`
 	Env     map[string]interface{} `json:"environment"`
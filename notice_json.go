@@ -0,0 +1,323 @@
+package gobrake
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Maximum nesting of values in Context, Env, Session and Params. Deeper
+// values are replaced with truncatedValue.
+const maxEncodeDepth = 16
+
+const circularValue = "[Circular]"
+
+// MarshalJSON encodes the notice. Values in Context, Env, Session and Params
+// that encoding/json can't encode are converted, so the notice is never
+// lost because of an encoding error:
+//   - json.Marshaler and encoding.TextMarshaler are used when they succeed
+//   - errors are replaced with their messages
+//   - fmt.Stringer is used for values other than booleans, numbers and
+//     strings
+//   - channels, functions and unsafe pointers are replaced with their type,
+//     e.g. "[chan int]"
+//   - cycles are replaced with "[Circular]"
+//   - values nested deeper than 16 levels are replaced with "[Truncated]"
+//   - NaN and infinite floats are replaced with strings
+func (n *Notice) MarshalJSON() ([]byte, error) {
+	type notice Notice
+	c := notice(*n)
+	c.Context = safeMap(n.Context)
+	c.Env = safeMap(n.Env)
+	c.Session = safeMap(n.Session)
+	c.Params = safeMap(n.Params)
+	return json.Marshal(&c)
+}
+
+func safeMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	e := &safeEncoder{
+		seen: make(map[uintptr]struct{}),
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = e.value(reflect.ValueOf(v), 1)
+	}
+	return out
+}
+
+// safeValue converts v to a value that encoding/json can encode. The result
+// contains only nil, booleans, int64, uint64, float64, strings, []byte,
+// json.RawMessage, []interface{} and map[string]interface{} values.
+func safeValue(v interface{}) interface{} {
+	e := &safeEncoder{
+		seen: make(map[uintptr]struct{}),
+	}
+	return e.value(reflect.ValueOf(v), 1)
+}
+
+// safeEncoder converts values to values that encoding/json can encode.
+type safeEncoder struct {
+	// Pointers, maps and slices on the path to the current value.
+	seen map[uintptr]struct{}
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+func (e *safeEncoder) value(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	if s, ok := e.method(v); ok {
+		return s
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return f
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, 128)
+	case reflect.String:
+		return v.String()
+	case reflect.Interface:
+		return e.value(v.Elem(), depth)
+	case reflect.Ptr:
+		return e.visit(v, func() interface{} {
+			return e.value(v.Elem(), depth)
+		})
+	}
+
+	if depth > maxEncodeDepth {
+		return truncatedValue
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		return e.visit(v, func() interface{} {
+			out := make(map[string]interface{}, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				out[mapKey(iter.Key())] = e.value(iter.Value(), depth+1)
+			}
+			return out
+		})
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Encoded as base64 like encoding/json does.
+			return v.Bytes()
+		}
+		return e.visit(v, func() interface{} {
+			return e.array(v, depth)
+		})
+	case reflect.Array:
+		return e.array(v, depth)
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		e.structFields(out, v, depth)
+		return out
+	default:
+		// Chan, Func and UnsafePointer.
+		return "[" + v.Type().String() + "]"
+	}
+}
+
+// visit calls fn unless v is already on the path to the current value.
+func (e *safeEncoder) visit(v reflect.Value, fn func() interface{}) interface{} {
+	ptr := v.Pointer()
+	if _, ok := e.seen[ptr]; ok {
+		return circularValue
+	}
+	e.seen[ptr] = struct{}{}
+	defer delete(e.seen, ptr)
+	return fn()
+}
+
+func (e *safeEncoder) array(v reflect.Value, depth int) []interface{} {
+	out := make([]interface{}, v.Len())
+	for i := range out {
+		out[i] = e.value(v.Index(i), depth+1)
+	}
+	return out
+}
+
+// structFields adds fields of the struct v to out using the same names as
+// encoding/json. Fields of embedded structs are added unless out already
+// has a field with the same name.
+func (e *safeEncoder) structFields(out map[string]interface{}, v reflect.Value, depth int) {
+	t := v.Type()
+	var embedded []reflect.Value
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts := parseJSONTag(f.Tag.Get("json"))
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, fv)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			// Unexported.
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyValue(fv) {
+			continue
+		}
+		out[name] = e.value(fv, depth+1)
+	}
+
+	for _, fv := range embedded {
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		fields := make(map[string]interface{})
+		e.structFields(fields, fv, depth)
+		for k, el := range fields {
+			if _, ok := out[k]; !ok {
+				out[k] = el
+			}
+		}
+	}
+}
+
+// method converts v with its MarshalJSON, MarshalText, Error or String
+// method. It reports whether v was converted.
+func (e *safeEncoder) method(v reflect.Value) (res interface{}, ok bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	t := v.Type()
+	switch {
+	case t.Implements(jsonMarshalerType),
+		t.Implements(textMarshalerType),
+		t.Implements(errorType):
+	case t.Implements(stringerType):
+		switch v.Kind() {
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			// Encoded as is, e.g. time.Duration.
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			res, ok = fmt.Sprintf("[%s: panic: %v]", t, r), true
+		}
+	}()
+
+	switch x := v.Interface().(type) {
+	case json.Marshaler:
+		b, err := x.MarshalJSON()
+		if err != nil {
+			return fmt.Sprintf("[%s: %s]", t, err), true
+		}
+		if !json.Valid(b) {
+			return fmt.Sprintf("[%s: invalid JSON]", t), true
+		}
+		return json.RawMessage(b), true
+	case encoding.TextMarshaler:
+		b, err := x.MarshalText()
+		if err != nil {
+			return fmt.Sprintf("[%s: %s]", t, err), true
+		}
+		return string(b), true
+	case error:
+		return x.Error(), true
+	case fmt.Stringer:
+		return x.String(), true
+	}
+	return nil, false
+}
+
+func mapKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if k.CanInterface() {
+		if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+			if b, err := tm.MarshalText(); err == nil {
+				return string(b)
+			}
+		}
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10)
+	}
+	return fmt.Sprint(k)
+}
+
+func parseJSONTag(tag string) (name, opts string) {
+	if i := strings.Index(tag, ","); i != -1 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
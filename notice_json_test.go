@@ -0,0 +1,216 @@
+package gobrake_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/airbrake/gobrake/v5"
+)
+
+type jsonMarshaler struct{}
+
+func (jsonMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{"custom":true}`), nil
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("marshal failed")
+}
+
+type invalidMarshaler struct{}
+
+func (invalidMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{`), nil
+}
+
+type panickingStringer struct{}
+
+func (panickingStringer) String() string {
+	panic("boom")
+}
+
+type stringer struct {
+	ch chan int
+}
+
+func (stringer) String() string {
+	return "stringer"
+}
+
+type node struct {
+	Name string
+	Next *node
+}
+
+type Base struct {
+	ID   int
+	Name string
+}
+
+type tagged struct {
+	Base
+	Name     string `json:"name"`
+	Skipped  string `json:"-"`
+	Empty    string `json:"empty,omitempty"`
+	Callback func()
+	private  int
+}
+
+var _ = Describe("Notice.MarshalJSON", func() {
+	encodeParam := func(v interface{}) interface{} {
+		notice := gobrake.NewNotice(errors.New("test"), nil, 0)
+		notice.Params["value"] = v
+
+		b, err := json.Marshal(notice)
+		Expect(err).NotTo(HaveOccurred())
+
+		var out struct {
+			Params map[string]interface{} `json:"params"`
+		}
+		Expect(json.Unmarshal(b, &out)).To(Succeed())
+		return out.Params["value"]
+	}
+
+	It("encodes supported values as encoding/json does", func() {
+		Expect(encodeParam("str")).To(Equal("str"))
+		Expect(encodeParam(42)).To(Equal(42.0))
+		Expect(encodeParam(true)).To(BeTrue())
+		Expect(encodeParam(nil)).To(BeNil())
+		Expect(encodeParam([]byte("hi"))).To(Equal("aGk="))
+		Expect(encodeParam(time.Second)).To(Equal(1e9))
+		Expect(encodeParam([]int{1, 2})).To(Equal([]interface{}{1.0, 2.0}))
+		Expect(encodeParam(map[string]int{"a": 1})).To(Equal(map[string]interface{}{"a": 1.0}))
+	})
+
+	It("replaces channels and functions with their type", func() {
+		Expect(encodeParam(make(chan int))).To(Equal("[chan int]"))
+		Expect(encodeParam(func() {})).To(Equal("[func()]"))
+	})
+
+	It("breaks cyclic maps", func() {
+		m := map[string]interface{}{"a": 1}
+		m["self"] = m
+
+		Expect(encodeParam(m)).To(Equal(map[string]interface{}{
+			"a":    1.0,
+			"self": "[Circular]",
+		}))
+	})
+
+	It("breaks cyclic pointers", func() {
+		n := &node{Name: "a"}
+		n.Next = n
+
+		Expect(encodeParam(n)).To(Equal(map[string]interface{}{
+			"Name": "a",
+			"Next": "[Circular]",
+		}))
+	})
+
+	It("breaks cyclic slices", func() {
+		s := make([]interface{}, 2)
+		s[0] = 1
+		s[1] = s
+
+		Expect(encodeParam(s)).To(Equal([]interface{}{1.0, "[Circular]"}))
+	})
+
+	It("does not treat shared values as cycles", func() {
+		shared := map[string]int{"a": 1}
+
+		Expect(encodeParam([]interface{}{shared, shared})).To(Equal([]interface{}{
+			map[string]interface{}{"a": 1.0},
+			map[string]interface{}{"a": 1.0},
+		}))
+	})
+
+	It("uses json.Marshaler", func() {
+		Expect(encodeParam(jsonMarshaler{})).To(Equal(map[string]interface{}{"custom": true}))
+	})
+
+	It("replaces failing json.Marshaler with the error", func() {
+		Expect(encodeParam(failingMarshaler{})).To(Equal("[gobrake_test.failingMarshaler: marshal failed]"))
+		Expect(encodeParam(invalidMarshaler{})).To(Equal("[gobrake_test.invalidMarshaler: invalid JSON]"))
+	})
+
+	It("uses encoding.TextMarshaler", func() {
+		Expect(encodeParam(net.IPv4(127, 0, 0, 1))).To(Equal("127.0.0.1"))
+	})
+
+	It("replaces errors with their messages", func() {
+		Expect(encodeParam(errors.New("oops"))).To(Equal("oops"))
+	})
+
+	It("uses fmt.Stringer", func() {
+		Expect(encodeParam(stringer{ch: make(chan int)})).To(Equal("stringer"))
+	})
+
+	It("recovers from panicking methods", func() {
+		Expect(encodeParam(panickingStringer{})).To(Equal("[gobrake_test.panickingStringer: panic: boom]"))
+	})
+
+	It("replaces NaN, infinite floats and complex numbers with strings", func() {
+		Expect(encodeParam(math.NaN())).To(Equal("NaN"))
+		Expect(encodeParam(math.Inf(1))).To(Equal("+Inf"))
+		Expect(encodeParam(complex(1, 2))).To(Equal("(1+2i)"))
+	})
+
+	It("converts map keys to strings", func() {
+		Expect(encodeParam(map[int]string{1: "a"})).To(Equal(map[string]interface{}{"1": "a"}))
+		Expect(encodeParam(map[interface{}]int{true: 1})).To(Equal(map[string]interface{}{"true": 1.0}))
+	})
+
+	It("encodes structs using json tags", func() {
+		v := tagged{
+			Base:     Base{ID: 1, Name: "base"},
+			Name:     "name",
+			Skipped:  "skipped",
+			Callback: func() {},
+			private:  1,
+		}
+
+		Expect(encodeParam(v)).To(Equal(map[string]interface{}{
+			"ID":       1.0,
+			"Name":     "base",
+			"name":     "name",
+			"Callback": "[func()]",
+		}))
+	})
+
+	It("caps depth", func() {
+		var v interface{} = "leaf"
+		for i := 0; i < 20; i++ {
+			v = []interface{}{v}
+		}
+
+		b, err := json.Marshal(encodeParam(v))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring(`"[Truncated]"`))
+		Expect(string(b)).NotTo(ContainSubstring("leaf"))
+		// Params value at depth 1 and 15 nested arrays.
+		arrays := strings.Replace(string(b), `"[Truncated]"`, "", 1)
+		Expect(strings.Count(arrays, "[")).To(Equal(16))
+	})
+
+	It("encodes Context, Env and Session", func() {
+		notice := gobrake.NewNotice(errors.New("test"), nil, 0)
+		notice.Context["ch"] = make(chan int)
+		notice.Env = map[string]interface{}{"fn": func() {}}
+		notice.Session = map[string]interface{}{"err": errors.New("oops")}
+
+		b, err := json.Marshal(notice)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring(`"ch":"[chan int]"`))
+		Expect(string(b)).To(ContainSubstring(`"environment":{"fn":"[func()]"}`))
+		Expect(string(b)).To(ContainSubstring(`"session":{"err":"oops"}`))
+	})
+})
@@ -2,14 +2,12 @@ package gobrake
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"regexp"
 	"runtime"
-	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,6 +19,7 @@ const (
 	userAgent           = notifierName + "/" + notifierVersion
 	waitTimeout         = 5 * time.Second
 	flushPeriod         = 15 * time.Second
+	bucketResolution    = time.Minute
 	httpEnhanceYourCalm = 420
 	maxNoticeLen        = 64 * 1024
 )
@@ -75,16 +74,34 @@ type NotifierOptions struct {
 	// Controls the remote config feature.
 	DisableRemoteConfig bool
 
+	// File where the last fetched remote config is stored. The config is
+	// loaded from the file on start, so the notifier doesn't run with
+	// defaults until the first fetch. Default is no cache.
+	RemoteConfigCacheFile string
+
 	// Environment such as production or development.
 	Environment string
 
-	// Git revision. Default is SOURCE_VERSION on Heroku.
+	// Git revision. Default is SOURCE_VERSION on Heroku or vcs.revision
+	// stamped into the binary by the Go toolchain.
 	Revision string
 
 	// List of keys containing sensitive information that must be filtered out.
-	// Default is password, secret.
+	// Default is password, secret. Keys from the remote config are appended.
 	KeysBlocklist []interface{}
 
+	// Fraction of notices that are sent to Airbrake, from 0 to 1.
+	// Default is 1 or the value from the remote config.
+	SamplingRate float64
+
+	// Notices with these error types, e.g. *url.Error, are ignored. Types
+	// from the remote config are appended.
+	IgnoredErrorTypes []string
+
+	// Notices with error messages containing any of these strings are
+	// ignored. Messages from the remote config are appended.
+	IgnoredErrorMessages []string
+
 	// Disables code hunks.
 	DisableCodeHunks bool
 
@@ -94,12 +111,98 @@ type NotifierOptions struct {
 	// Controls the error reporting feature.
 	DisableAPM bool
 
+	// How often APM stats are sent to Airbrake.
+	// Default is 15s or the value from the remote config.
+	APMFlushPeriod time.Duration
+
+	// Time granularity of APM stats. Requests, queries and queue jobs that
+	// start in the same bucket are aggregated together.
+	// Default is 1m
+	APMBucketResolution time.Duration
+
+	// Maximum number of distinct routes, queries and queues per flush period
+	// and kind of stats. Routes are counted per method. Stats beyond the
+	// limit are aggregated under "other".
+	// Default is 0 (no limit)
+	APMMaxKeys int
+
+	// Route templates such as /users/:id. Routes that match a template,
+	// e.g. /users/123, are reported as the template. Segments starting with
+	// ":" match any segment and a last segment "*" matches the rest of the
+	// route.
+	RouteTemplates []string
+
+	// Enables replacing numeric ids, UUIDs, hashes and dates in routes
+	// that don't match RouteTemplates with placeholders such as :id.
+	// Default is false
+	EnableRouteNormalization bool
+
 	// http.Client that is used to interact with Airbrake API.
 	HTTPClient *http.Client
 
 	// Controls the backlog reporting feature.
 	// Default is false
 	DisableBacklog bool
+
+	// Compresses notice and APM payloads with gzip.
+	// Default is false
+	EnableCompression bool
+
+	// Fingerprinter returns fingerprint for notices that don't have one.
+	// See DefaultFingerprinter.
+	Fingerprinter func(*Notice) string
+
+	// Maximum number of breadcrumbs kept by the notifier and attached to
+	// notices. Default is 25.
+	MaxBreadcrumbs int
+
+	// List of package path prefixes, e.g. error helpers and logger adapters,
+	// whose frames are stripped from the top of backtraces.
+	IgnoredPackages []string
+
+	// Maximum number of frames in a backtrace. Default is 32.
+	MaxBacktraceDepth int
+
+	// Attaches parsed dump of all goroutines to critical notices, e.g.
+	// notices sent by NotifyOnPanic.
+	// Default is false
+	EnableGoroutineDump bool
+
+	// Maximum number of goroutines in the goroutine dump. Default is 50.
+	MaxGoroutines int
+
+	// Attaches the module dependencies of the binary to notices under
+	// context.buildInfo.dependencies.
+	// Default is false
+	EnableBuildDependencies bool
+
+	// Recovers panics in goroutines started by Notifier.Go and Group after
+	// they are reported instead of re-panicking.
+	// Default is false
+	SwallowPanics bool
+
+	// Logger receives messages of the notifier. Default is a logger that
+	// writes info and higher levels to the logger set with SetLogger.
+	Logger Logger
+
+	// Logs a summary of every payload sent to Airbrake at debug level. The
+	// default logger logs debug messages when Debug is set.
+	// Default is false
+	Debug bool
+
+	// Additional Airbrake projects that notices and APM stats are
+	// delivered to. The remote config of the notifier project applies to
+	// all destinations.
+	Destinations []Destination
+}
+
+// defaultKeysBlocklist returns the keys filtered out when
+// NotifierOptions.KeysBlocklist is not set.
+func defaultKeysBlocklist() []interface{} {
+	return []interface{}{
+		regexp.MustCompile("password"),
+		regexp.MustCompile("secret"),
+	}
 }
 
 func (opt *NotifierOptions) init() {
@@ -120,16 +223,45 @@ func (opt *NotifierOptions) init() {
 		opt.Revision = os.Getenv("SOURCE_VERSION")
 	}
 
-	if opt.KeysBlocklist == nil {
-		opt.KeysBlocklist = []interface{}{
-			regexp.MustCompile("password"),
-			regexp.MustCompile("secret"),
+	if opt.Revision == "" {
+		if info := getBuildInfo(); info != nil {
+			opt.Revision = info.Revision
 		}
 	}
 
+	if opt.KeysBlocklist == nil {
+		opt.KeysBlocklist = defaultKeysBlocklist()
+	}
+
 	if opt.HTTPClient == nil {
 		opt.HTTPClient = defaultHTTPClient()
 	}
+
+	if opt.MaxBreadcrumbs <= 0 {
+		opt.MaxBreadcrumbs = defaultMaxBreadcrumbs
+	}
+
+	if opt.MaxBacktraceDepth <= 0 {
+		opt.MaxBacktraceDepth = defaultMaxBacktraceDepth
+	}
+
+	if opt.MaxGoroutines <= 0 {
+		opt.MaxGoroutines = defaultMaxGoroutines
+	}
+}
+
+func (opt *NotifierOptions) apmFlushPeriod() time.Duration {
+	if opt.APMFlushPeriod > 0 {
+		return opt.APMFlushPeriod
+	}
+	return flushPeriod
+}
+
+func (opt *NotifierOptions) apmBucketResolution() time.Duration {
+	if opt.APMBucketResolution > 0 {
+		return opt.APMBucketResolution
+	}
+	return bucketResolution
 }
 
 // Makes a shallow copy (without copying slices or nested structs; because we
@@ -141,66 +273,106 @@ func (opt *NotifierOptions) Copy() *NotifierOptions {
 		Host:                      opt.Host,
 		APMHost:                   opt.APMHost,
 		RemoteConfigHost:          opt.RemoteConfigHost,
+		DisableRemoteConfig:       opt.DisableRemoteConfig,
+		RemoteConfigCacheFile:     opt.RemoteConfigCacheFile,
 		Environment:               opt.Environment,
 		Revision:                  opt.Revision,
 		KeysBlocklist:             opt.KeysBlocklist,
+		SamplingRate:              opt.SamplingRate,
+		IgnoredErrorTypes:         opt.IgnoredErrorTypes,
+		IgnoredErrorMessages:      opt.IgnoredErrorMessages,
 		DisableCodeHunks:          opt.DisableCodeHunks,
 		DisableErrorNotifications: opt.DisableErrorNotifications,
 		DisableAPM:                opt.DisableAPM,
+		APMFlushPeriod:            opt.APMFlushPeriod,
+		APMBucketResolution:       opt.APMBucketResolution,
+		APMMaxKeys:                opt.APMMaxKeys,
+		RouteTemplates:            opt.RouteTemplates,
+		EnableRouteNormalization:  opt.EnableRouteNormalization,
 		HTTPClient:                opt.HTTPClient,
 		DisableBacklog:            opt.DisableBacklog,
+		EnableCompression:         opt.EnableCompression,
+		Fingerprinter:             opt.Fingerprinter,
+		MaxBreadcrumbs:            opt.MaxBreadcrumbs,
+		IgnoredPackages:           opt.IgnoredPackages,
+		MaxBacktraceDepth:         opt.MaxBacktraceDepth,
+		EnableGoroutineDump:       opt.EnableGoroutineDump,
+		MaxGoroutines:             opt.MaxGoroutines,
+		EnableBuildDependencies:   opt.EnableBuildDependencies,
+		SwallowPanics:             opt.SwallowPanics,
+		Logger:                    opt.Logger,
+		Debug:                     opt.Debug,
+		Destinations:              opt.Destinations,
 	}
 }
 
 type Notifier struct {
-	opt     *NotifierOptions
-	filters []filter
+	opts         *sharedOptions
+	filters      []filter
+	destinations destinations
 
 	inFlight int32 // atomic
 	limit    chan struct{}
 	wg       sync.WaitGroup
 
+	apm     *apmScheduler
 	Routes  *routes
 	Queries *queryStats
 	Queues  *queueStats
 
-	rateLimitReset uint32 // atomic
-	_closed        uint32 // atomic
+	// Breadcrumbs recorded by the notifier. The most recent ones are
+	// attached to every notice.
+	Breadcrumbs *Breadcrumbs
+
+	_closed uint32 // atomic
 
 	remoteConfig *remoteConfig
 }
 
 func NewNotifierWithOptions(opt *NotifierOptions) *Notifier {
 	opt.init()
+	if err := opt.Validate(); err != nil {
+		opt.log().Error("invalid notifier options", "error", err)
+	}
 
+	opts := newSharedOptions(opt)
+	apm := newAPMScheduler(opts)
 	n := &Notifier{
-		opt:   opt,
+		opts:  opts,
 		limit: make(chan struct{}, 2*runtime.NumCPU()),
 
-		Routes:  newRoutes(opt),
-		Queries: newQueryStats(opt),
-		Queues:  newQueueStats(opt),
+		apm: apm,
+
+		Breadcrumbs: NewBreadcrumbs(opt.MaxBreadcrumbs),
 
-		remoteConfig: newRemoteConfig(opt),
+		remoteConfig: newRemoteConfig(opts),
 	}
+	dests := newDestinations(opts, opt, &n.wg)
+	n.destinations = dests
+	n.Routes = newRoutes(opts, dests, apm)
+	n.Queries = newQueryStats(opts, dests, apm)
+	n.Queues = newQueueStats(opts, dests, apm)
+	n.Queries.breadcrumbs = n.Breadcrumbs
 
 	n.AddFilter(httpUnsolicitedResponseFilter)
+	n.AddFilter(newIgnoredErrorsFilter(opts))
+	n.AddFilter(newSamplingFilter(opts))
 	n.AddFilter(newNotifierFilter(n))
-	n.AddFilter(gitFilter)
-	if !opt.DisableCodeHunks {
-		n.AddFilter(codeHunksFilter)
-	}
+	n.AddFilter(newBreadcrumbsFilter(n))
+	n.AddFilter(newGoroutinesFilter(opts))
+	n.AddFilter(newGitFilter(opts))
+	n.AddFilter(newBuildDependenciesFilter(opts))
+	n.AddFilter(newBacktraceFilter(opts))
+	n.AddFilter(newCodeHunksFilter(opts))
+	n.AddFilter(modulePathFilter)
 	n.AddFilter(gopathFilter)
-
-	if len(opt.KeysBlocklist) > 0 {
-		n.AddFilter(NewBlocklistKeysFilter(opt.KeysBlocklist...))
-	}
+	n.AddFilter(newFingerprintFilter(opts))
+	n.AddFilter(newKeysBlocklistFilter(opts))
 
 	if !opt.DisableRemoteConfig {
 		n.remoteConfig.Poll()
 	}
 
-	newBacklog(opt)
 	return n
 }
 
@@ -216,12 +388,39 @@ func (n *Notifier) AddFilter(fn func(*Notice) *Notice) {
 	n.filters = append(n.filters, fn)
 }
 
+// Options returns a copy of the effective notifier options, i.e. local
+// options with the remote config applied.
+func (n *Notifier) Options() *NotifierOptions {
+	return n.opts.Load().Copy()
+}
+
+// UpdateOptions safely changes notifier options at runtime. fn receives a
+// copy of the options set by the application; the remote config is applied
+// on top of the changed options. Slices must be replaced rather than
+// modified in place. MaxBreadcrumbs, DisableRemoteConfig and
+// RemoteConfigCacheFile only take effect when the notifier is created.
+func (n *Notifier) UpdateOptions(fn func(*NotifierOptions)) {
+	n.opts.Update(fn)
+}
+
+// RemoteConfig returns the current remote config.
+func (n *Notifier) RemoteConfig() RemoteConfigJSON {
+	return n.remoteConfig.Config()
+}
+
+// OnRemoteConfigChange registers fn that is called with the old and the new
+// remote config every time the remote config changes. fn is called after
+// the new config is applied to the notifier options.
+func (n *Notifier) OnRemoteConfigChange(fn func(old, new RemoteConfigJSON)) {
+	n.remoteConfig.OnChange(fn)
+}
+
 // Notify notifies Airbrake about the error.
 func (n *Notifier) Notify(e interface{}, req *http.Request) {
-	if n.opt.DisableErrorNotifications {
-		logger.Printf(
-			"error notifications are disabled, will not deliver notice=%q",
-			e,
+	if opt := n.opts.Load(); opt.DisableErrorNotifications {
+		opt.log().Info(
+			"error notifications are disabled, will not deliver",
+			"notice", e,
 		)
 		return
 	}
@@ -232,8 +431,13 @@ func (n *Notifier) Notify(e interface{}, req *http.Request) {
 
 // Notice returns Aibrake notice created from error and request. depth
 // determines which call frame to use when constructing backtrace.
+// Breadcrumbs stored in the request context are attached to the notice.
 func (n *Notifier) Notice(err interface{}, req *http.Request, depth int) *Notice {
-	return NewNotice(err, req, depth+1)
+	notice := NewNotice(err, req, depth+1)
+	if req != nil {
+		addContextBreadcrumbs(req.Context(), notice)
+	}
+	return notice
 }
 
 type sendResponse struct {
@@ -257,91 +461,7 @@ func (n *Notifier) sendNotice(notice *Notice) (string, error) {
 			return "", nil
 		}
 	}
-
-	if time.Now().Unix() < int64(atomic.LoadUint32(&n.rateLimitReset)) {
-		return "", errIPRateLimited
-	}
-
-	buf := buffers.Get().(*bytes.Buffer)
-	defer buffers.Put(buf)
-
-	buf.Reset()
-	err := json.NewEncoder(buf).Encode(notice)
-	if err != nil {
-		return "", err
-	}
-
-	if buf.Len() > maxNoticeLen {
-		return "", errNoticeTooBig
-	}
-
-	req, err := http.NewRequest(
-		http.MethodPost,
-		fmt.Sprintf("%s/api/v3/projects/%d/notices",
-			n.opt.Host, n.opt.ProjectId),
-		buf,
-	)
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+n.opt.ProjectKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-	resp, err := n.opt.HTTPClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	buf.Reset()
-	_, err = buf.ReadFrom(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		var sendResp sendResponse
-		err = json.NewDecoder(buf).Decode(&sendResp)
-		if err != nil {
-			return "", err
-		}
-		return sendResp.Id, nil
-	}
-
-	switch resp.StatusCode {
-	case http.StatusUnauthorized:
-		return "", errUnauthorized
-	case http.StatusTooManyRequests:
-		delayStr := resp.Header.Get("X-RateLimit-Delay")
-		delay, err := strconv.ParseInt(delayStr, 10, 64)
-		if err == nil {
-			atomic.StoreUint32(&n.rateLimitReset, uint32(time.Now().Unix()+delay))
-		}
-		var sendResp sendResponse
-		err = json.NewDecoder(buf).Decode(&sendResp)
-		if err != nil {
-			return "", err
-		}
-		return "", errors.New(sendResp.Message)
-	case httpEnhanceYourCalm:
-		return "", errAccountRateLimited
-	case http.StatusRequestEntityTooLarge:
-		return "", errNoticeTooBig
-	case http.StatusBadRequest:
-		var sendResp sendResponse
-		err = json.NewDecoder(buf).Decode(&sendResp)
-		if err != nil {
-			return "", err
-		}
-		return "", errors.New(sendResp.Message)
-	case 404, 408, 409, 410, 500, 502, 504:
-		setNoticeBacklog(notice)
-	}
-
-	err = fmt.Errorf("got unexpected response status=%q", resp.Status)
-	logger.Printf("SendNotice failed reporting notice=%q: %s", notice, err)
-	return "", err
+	return n.destinations.sendNotice(notice)
 }
 
 // SendNoticeAsync is like SendNotice, but sends notice asynchronously.
@@ -365,9 +485,9 @@ func (n *Notifier) SendNoticeAsync(notice *Notice) {
 
 		notice.Id, notice.Error = n.sendNotice(notice)
 		if notice.Error != nil {
-			logger.Printf(
-				"sendNotice failed reporting notice=%q: %s",
-				notice, notice.Error,
+			n.opts.Load().log().Error(
+				"sendNotice failed",
+				"notice", notice, "error", notice.Error,
 			)
 		}
 
@@ -386,9 +506,9 @@ func (n *Notifier) NotifyOnPanic() {
 		notice.Context["severity"] = "critical"
 		_, err := n.SendNotice(notice)
 		if err != nil {
-			logger.Printf(
-				"SendNotice failed reporting notice=%q: %s",
-				notice, err,
+			n.opts.Load().log().Error(
+				"SendNotice failed",
+				"notice", notice, "error", err,
 			)
 		}
 
@@ -408,11 +528,19 @@ func (n *Notifier) Close() error {
 	return n.CloseTimeout(waitTimeout)
 }
 
-// CloseTimeout waits for pending requests to finish with a custom input timeout and then closes the notifier.
+// CloseTimeout sends pending APM stats, waits for pending requests to finish with a custom input timeout and then closes the notifier.
 func (n *Notifier) CloseTimeout(timeout time.Duration) error {
 	if !atomic.CompareAndSwapUint32(&n._closed, 0, 1) {
 		return nil
 	}
+	n.destinations.close()
+
+	// APM stats are sent within the timeout too.
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.apm.close()
+	}()
 	return n.waitTimeout(timeout)
 }
 
@@ -2,6 +2,7 @@ package gobrake_test
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/rand"
 	"encoding/json"
@@ -15,7 +16,9 @@ import (
 	"os"
 	"regexp"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -54,7 +57,14 @@ var _ = Describe("Notifier", func() {
 		handler := func(w http.ResponseWriter, req *http.Request) {
 			sendNoticeReq = req
 
-			b, err := io.ReadAll(req.Body)
+			var body io.Reader = req.Body
+			if req.Header.Get("Content-Encoding") == "gzip" {
+				zr, err := gzip.NewReader(req.Body)
+				Expect(err).To(BeNil())
+				body = zr
+			}
+
+			b, err := io.ReadAll(body)
 			if err != nil {
 				panic(err)
 			}
@@ -123,6 +133,23 @@ var _ = Describe("Notifier", func() {
 		Expect(len(e.Backtrace)).NotTo(BeZero())
 	})
 
+	It("sends notice with params that encoding/json can't encode", func() {
+		cyclic := map[string]interface{}{}
+		cyclic["self"] = cyclic
+
+		notice := notifier.Notice(errors.New("hello"), nil, 0)
+		notice.Params["ch"] = make(chan int)
+		notice.Params["cyclic"] = cyclic
+
+		id, err := notifier.SendNotice(notice)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("123"))
+		Expect(sentNotice.Params).To(Equal(map[string]interface{}{
+			"ch":     "[chan int]",
+			"cyclic": map[string]interface{}{"self": "[Circular]"},
+		}))
+	})
+
 	Context("DisableCodeHunks", func() {
 		BeforeEach(func() {
 			opt.DisableCodeHunks = true
@@ -141,6 +168,126 @@ var _ = Describe("Notifier", func() {
 		})
 	})
 
+	Context("EnableCompression", func() {
+		BeforeEach(func() {
+			opt.EnableCompression = true
+		})
+
+		It("sends gzip-compressed notice", func() {
+			notify("hello", nil)
+
+			Expect(sendNoticeReq.Header.Get("Content-Encoding")).To(Equal("gzip"))
+			Expect(sentNotice.Errors[0].Message).To(Equal("hello"))
+		})
+	})
+
+	Context("when EnableCompression is disabled", func() {
+		It("sends uncompressed notice", func() {
+			notify("hello", nil)
+
+			Expect(sendNoticeReq.Header.Get("Content-Encoding")).To(BeEmpty())
+		})
+	})
+
+	Context("Fingerprinter", func() {
+		BeforeEach(func() {
+			opt.Fingerprinter = func(notice *gobrake.Notice) string {
+				return "fp:" + gobrake.NormalizeMessage(notice.Errors[0].Message)
+			}
+		})
+
+		It("sets notice fingerprint", func() {
+			notify("user 42 not found", nil)
+
+			Expect(sentNotice.Fingerprint).To(Equal("fp:user <num> not found"))
+		})
+
+		It("does not override fingerprint set on notice", func() {
+			notice := notifier.Notice("hello", nil, 3)
+			notice.Fingerprint = "custom"
+			notify(notice, nil)
+
+			Expect(sentNotice.Fingerprint).To(Equal("custom"))
+		})
+	})
+
+	Context("IgnoredPackages", func() {
+		BeforeEach(func() {
+			opt.IgnoredPackages = []string{"github.com/airbrake/gobrake/v5/internal/testpkg1"}
+		})
+
+		It("strips frames of ignored packages from the top", func() {
+			notify(testpkg1.Foo(), nil)
+
+			frame := sentNotice.Errors[0].Backtrace[0]
+			Expect(frame.File).To(HaveSuffix("notifier_test.go"))
+			Expect(sentNotice.Context["component"]).To(Equal("github.com/airbrake/gobrake/v5_test"))
+		})
+	})
+
+	Context("MaxBacktraceDepth", func() {
+		BeforeEach(func() {
+			opt.MaxBacktraceDepth = 2
+		})
+
+		It("trims backtrace", func() {
+			notify("hello", nil)
+
+			Expect(sentNotice.Errors[0].Backtrace).To(HaveLen(2))
+		})
+	})
+
+	It("classifies frames", func() {
+		notify("hello", nil)
+
+		origins := make(map[string]bool)
+		for _, frame := range sentNotice.Errors[0].Backtrace {
+			Expect(frame.InApp).To(Equal(frame.Origin == "app"))
+			origins[frame.Origin] = true
+		}
+		Expect(sentNotice.Errors[0].Backtrace[0].InApp).To(BeTrue())
+		Expect(origins).To(HaveKey("dependency"))
+	})
+
+	Context("Breadcrumbs", func() {
+		It("attaches notifier breadcrumbs", func() {
+			notifier.Breadcrumbs.Add(gobrake.Breadcrumb{
+				Category: gobrake.BreadcrumbLog,
+				Message:  "user logged in",
+			})
+			notify("hello", nil)
+
+			bcs := sentNotice.Context["breadcrumbs"].([]interface{})
+			Expect(bcs).To(HaveLen(1))
+			bc := bcs[0].(map[string]interface{})
+			Expect(bc["category"]).To(Equal("log"))
+			Expect(bc["message"]).To(Equal("user logged in"))
+		})
+
+		It("attaches request context breadcrumbs", func() {
+			notifier.Breadcrumbs.Add(gobrake.Breadcrumb{Message: "global"})
+
+			b := gobrake.NewBreadcrumbs(10)
+			b.Add(gobrake.Breadcrumb{Message: "request"})
+			req, err := http.NewRequestWithContext(
+				gobrake.ContextWithBreadcrumbs(context.Background(), b),
+				"GET", "http://foo/bar", nil,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			notify("hello", req)
+
+			bcs := sentNotice.Context["breadcrumbs"].([]interface{})
+			Expect(bcs).To(HaveLen(1))
+			Expect(bcs[0].(map[string]interface{})["message"]).To(Equal("request"))
+		})
+
+		It("does not attach empty breadcrumbs", func() {
+			notify("hello", nil)
+
+			Expect(sentNotice.Context).NotTo(HaveKey("breadcrumbs"))
+		})
+	})
+
 	Context("DisableErrorNotifications", func() {
 		Context("when it is enabled", func() {
 			var origLogger *log.Logger
@@ -243,13 +390,14 @@ var _ = Describe("Notifier", func() {
 		Expect(e.Message).To(Equal("Test"))
 
 		frame := e.Backtrace[0]
-		Expect(frame.File).To(ContainSubstring("gobrake/internal/testpkg1/testhelper.go"))
+		Expect(frame.File).To(Equal("/PROJECT_ROOT/internal/testpkg1/testhelper.go"))
+		Expect(frame.Module).To(Equal("github.com/airbrake/gobrake/v5"))
 		Expect(frame.Line).To(Equal(10))
 		Expect(frame.Func).To(Equal("Bar"))
 		Expect(frame.Code[10]).To(Equal(`	return errors.New("Test")`))
 
 		frame = e.Backtrace[1]
-		Expect(frame.File).To(ContainSubstring("gobrake/internal/testpkg1/testhelper.go"))
+		Expect(frame.File).To(Equal("/PROJECT_ROOT/internal/testpkg1/testhelper.go"))
 		Expect(frame.Line).To(Equal(6))
 		Expect(frame.Func).To(Equal("Foo"))
 		Expect(frame.Code[6]).To(Equal("\treturn Bar()"))
@@ -375,6 +523,156 @@ var _ = Describe("Notifier", func() {
 		Expect(sentNotice.Context["severity"]).To(Equal(customSeverity))
 	})
 
+	Context("EnableGoroutineDump", func() {
+		BeforeEach(func() {
+			opt.EnableGoroutineDump = true
+		})
+
+		It("attaches goroutines to critical notices", func() {
+			notice := notifier.Notice("hello", nil, 0)
+			notice.Context["severity"] = "critical"
+			notify(notice, nil)
+
+			goroutines, ok := sentNotice.Context["goroutines"].([]interface{})
+			Expect(ok).To(BeTrue())
+			Expect(goroutines).NotTo(BeEmpty())
+			Expect(sentNotice.Context["goroutinesCount"]).To(BeNumerically(">", len(goroutines)))
+
+			for _, g := range goroutines {
+				Expect(g).To(HaveKey("state"))
+				Expect(g).To(HaveKey("frames"))
+			}
+		})
+
+		It("does not attach goroutines to other notices", func() {
+			notify("hello", nil)
+
+			Expect(sentNotice.Context).NotTo(HaveKey("goroutines"))
+		})
+	})
+
+	Context("SwallowPanics", func() {
+		BeforeEach(func() {
+			opt.SwallowPanics = true
+		})
+
+		It("reports panics in group goroutines with the spawn site", func() {
+			g, _ := notifier.NewGroup(context.Background())
+			g.Go(func() error {
+				panic("hello")
+			})
+
+			err := g.Wait()
+			Expect(err).To(BeAssignableToTypeOf(&gobrake.PanicError{}))
+			Expect(err.Error()).To(Equal("panic: hello"))
+
+			Expect(sentNotice.Context["severity"]).To(Equal("critical"))
+			Expect(sentNotice.Errors).To(HaveLen(2))
+			Expect(sentNotice.Errors[0].Message).To(Equal("hello"))
+			spawn := sentNotice.Errors[1]
+			Expect(spawn.Type).To(Equal("goroutine"))
+			Expect(spawn.Backtrace[0].File).To(HaveSuffix("notifier_test.go"))
+		})
+	})
+
+	It("reports errors returned by group goroutines", func() {
+		g, c := notifier.NewGroup(context.Background())
+		g.Go(func() error {
+			return errors.New("boom")
+		})
+
+		Expect(g.Wait()).To(MatchError("boom"))
+		Expect(c.Err()).To(Equal(context.Canceled))
+
+		notifier.Flush()
+		Expect(sentNotice.Errors[0].Message).To(Equal("boom"))
+	})
+
+	It("limits active group goroutines", func() {
+		g, _ := notifier.NewGroup(context.Background())
+		g.SetLimit(1)
+
+		block := make(chan struct{})
+		g.Go(func() error {
+			<-block
+			return nil
+		})
+		Expect(g.TryGo(func() error { return nil })).To(BeFalse())
+
+		close(block)
+		Expect(g.Wait()).To(BeNil())
+		Expect(g.TryGo(func() error { return nil })).To(BeTrue())
+		Expect(g.Wait()).To(BeNil())
+	})
+
+	It("reports errors returned by goroutines started with Go", func() {
+		sentNotice = nil
+		// The filter runs once the notice is being sent, so Flush waits for
+		// it.
+		sending := make(chan struct{})
+		notifier.AddFilter(func(notice *gobrake.Notice) *gobrake.Notice {
+			close(sending)
+			return notice
+		})
+
+		notifier.Go(context.Background(), func(c context.Context) error {
+			return errors.New("go boom")
+		})
+
+		<-sending
+		notifier.Flush()
+		Expect(sentNotice).NotTo(BeNil())
+		Expect(sentNotice.Errors[0].Message).To(Equal("go boom"))
+	})
+
+	Context("IgnoredErrorTypes and IgnoredErrorMessages", func() {
+		BeforeEach(func() {
+			opt.IgnoredErrorTypes = []string{"*url.Error"}
+			opt.IgnoredErrorMessages = []string{"context canceled"}
+		})
+
+		It("ignores matching errors", func() {
+			sentNotice = nil
+
+			notify(&url.Error{Op: "Get", URL: "/", Err: io.EOF}, nil)
+			notify(errors.New("query failed: context canceled"), nil)
+			Expect(sentNotice).To(BeNil())
+
+			notify(errors.New("query failed"), nil)
+			Expect(sentNotice).NotTo(BeNil())
+		})
+	})
+
+	Context("SamplingRate", func() {
+		BeforeEach(func() {
+			opt.SamplingRate = 0.000001
+		})
+
+		It("drops sampled out notices", func() {
+			sentNotice = nil
+
+			for i := 0; i < 10; i++ {
+				notify("hello", nil)
+			}
+			Expect(sentNotice).To(BeNil())
+		})
+	})
+
+	It("applies options updated with UpdateOptions", func() {
+		notifier.UpdateOptions(func(opt *gobrake.NotifierOptions) {
+			opt.Environment = "staging"
+			opt.IgnoredErrorMessages = []string{"ignored"}
+		})
+		Expect(notifier.Options().Environment).To(Equal("staging"))
+
+		notify("hello", nil)
+		Expect(sentNotice.Context["environment"]).To(Equal("staging"))
+
+		sentNotice = nil
+		notify("ignored error", nil)
+		Expect(sentNotice).To(BeNil())
+	})
+
 	It("filters errors with message that starts with '(string)Unsolicited response received on idle HTTP channel starting with", func() {
 		sentNotice = nil
 
@@ -483,12 +781,25 @@ var _ = Describe("rate limiting", func() {
 
 var _ = Describe("Notice exceeds 64KB", func() {
 	var notifier *gobrake.Notifier
+	var sentNotice *gobrake.Notice
+	var sentLen int
 
 	const maxNoticeLen = 64 * 1024
 
 	BeforeEach(func() {
+		sentNotice = nil
 		handler := func(w http.ResponseWriter, req *http.Request) {
+			b, err := io.ReadAll(req.Body)
+			Expect(err).NotTo(HaveOccurred())
+			sentLen = len(b)
+
+			sentNotice = new(gobrake.Notice)
+			err = json.Unmarshal(b, sentNotice)
+			Expect(err).NotTo(HaveOccurred())
+
 			w.WriteHeader(http.StatusCreated)
+			_, err = w.Write([]byte(`{"id":"123"}`))
+			Expect(err).NotTo(HaveOccurred())
 		}
 		server := httptest.NewServer(http.HandlerFunc(handler))
 		configServer := newConfigServer()
@@ -505,12 +816,99 @@ var _ = Describe("Notice exceeds 64KB", func() {
 		Expect(notifier.Close()).NotTo(HaveOccurred())
 	})
 
-	It("returns notice too big error", func() {
+	It("truncates error message", func() {
 		b := make([]byte, maxNoticeLen+1)
 		_, err := rand.Read(b)
 		Expect(err).NotTo(HaveOccurred())
 
 		notice := notifier.Notice(string(b), nil, 3)
+		id, err := notifier.SendNotice(notice)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("123"))
+
+		Expect(sentLen).To(BeNumerically("<=", maxNoticeLen))
+		Expect(sentNotice.Context["truncated"]).To(BeTrue())
+		Expect(sentNotice.Errors[0].Message).To(HaveSuffix("[Truncated]"))
+	})
+
+	It("truncates long strings, nested values and arrays in params", func() {
+		long := strings.Repeat("a", maxNoticeLen)
+		nested := map[string]interface{}{"value": long}
+		for i := 0; i < 10; i++ {
+			nested = map[string]interface{}{"nested": nested}
+		}
+		list := make([]interface{}, 1000)
+		for i := range list {
+			list[i] = "item"
+		}
+
+		notice := notifier.Notice("hello", nil, 3)
+		notice.Params["long"] = long
+		notice.Params["nested"] = nested
+		notice.Params["list"] = list
+		notice.Env["long"] = long
+		notice.Session["long"] = long
+
+		_, err := notifier.SendNotice(notice)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sentLen).To(BeNumerically("<=", maxNoticeLen))
+		Expect(sentNotice.Context["truncated"]).To(BeTrue())
+		Expect(sentNotice.Errors[0].Message).To(Equal("hello"))
+		Expect(sentNotice.Params["long"]).To(HaveLen(1024 + len("[Truncated]")))
+		Expect(sentNotice.Params["list"]).To(HaveLen(128))
+		Expect(sentNotice.Env["long"]).To(HaveSuffix("[Truncated]"))
+		Expect(sentNotice.Session["long"]).To(HaveSuffix("[Truncated]"))
+	})
+
+	It("does not truncate notices that fit", func() {
+		notice := notifier.Notice("hello", nil, 3)
+		_, err := notifier.SendNotice(notice)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sentNotice.Context).NotTo(HaveKey("truncated"))
+	})
+
+	It("truncates context values", func() {
+		type user struct {
+			Name string
+		}
+
+		notice := notifier.Notice("hello", nil, 3)
+		notice.Context["long"] = strings.Repeat("a", maxNoticeLen)
+		notice.Context["user"] = user{Name: strings.Repeat("b", maxNoticeLen)}
+
+		_, err := notifier.SendNotice(notice)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sentLen).To(BeNumerically("<=", maxNoticeLen))
+		Expect(sentNotice.Context["truncated"]).To(BeTrue())
+		Expect(sentNotice.Context["long"]).To(HaveSuffix("[Truncated]"))
+		Expect(sentNotice.Context["user"]).To(HaveKeyWithValue("Name", HaveSuffix("[Truncated]")))
+	})
+
+	It("does not modify the notice", func() {
+		long := strings.Repeat("a", maxNoticeLen)
+		notice := notifier.Notice("hello", nil, 3)
+		notice.Params["long"] = long
+		notice.Context["long"] = long
+
+		_, err := notifier.SendNotice(notice)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sentNotice.Context["truncated"]).To(BeTrue())
+
+		Expect(notice.Params["long"]).To(Equal(long))
+		Expect(notice.Context["long"]).To(Equal(long))
+		Expect(notice.Context).NotTo(HaveKey("truncated"))
+	})
+
+	It("returns notice too big error when notice can't be truncated", func() {
+		b := make([]byte, maxNoticeLen+1)
+		_, err := rand.Read(b)
+		Expect(err).NotTo(HaveOccurred())
+
+		notice := notifier.Notice("hello", nil, 3)
+		notice.Errors[0].Type = string(b)
 		_, err = notifier.SendNotice(notice)
 		Expect(err).To(MatchError("gobrake: notice exceeds 64KB max size limit"))
 	})
@@ -547,6 +945,36 @@ var _ = Describe("server returns HTTP 400 error message", func() {
 	})
 })
 
+var _ = Describe("(*Notifier).CloseTimeout()", func() {
+	It("sends pending APM stats within the timeout", func() {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, req *http.Request) {
+				<-release
+				w.WriteHeader(http.StatusCreated)
+			},
+		))
+		DeferCleanup(server.Close)
+		defer close(release)
+
+		notifier := gobrake.NewNotifierWithOptions(&gobrake.NotifierOptions{
+			ProjectId:           1,
+			ProjectKey:          "key",
+			Host:                server.URL,
+			DisableRemoteConfig: true,
+		})
+
+		_, metric := gobrake.NewRouteMetric(context.TODO(), "GET", "/ping")
+		metric.StatusCode = http.StatusOK
+		Expect(notifier.Routes.Notify(context.TODO(), metric)).To(Succeed())
+
+		start := time.Now()
+		err := notifier.CloseTimeout(100 * time.Millisecond)
+		Expect(err).To(MatchError("wait timed out after 100ms"))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+})
+
 var _ = Describe("Notifier request filter", func() {
 	type routeStat struct {
 		Method     string
@@ -570,7 +998,14 @@ var _ = Describe("Notifier request filter", func() {
 		stats = new(routeStats)
 
 		handler := func(w http.ResponseWriter, req *http.Request) {
-			b, err := io.ReadAll(req.Body)
+			var body io.Reader = req.Body
+			if req.Header.Get("Content-Encoding") == "gzip" {
+				zr, err := gzip.NewReader(req.Body)
+				Expect(err).NotTo(HaveOccurred())
+				body = zr
+			}
+
+			b, err := io.ReadAll(body)
 			Expect(err).NotTo(HaveOccurred())
 
 			err = json.Unmarshal(b, &stats)
@@ -600,6 +1035,18 @@ var _ = Describe("Notifier request filter", func() {
 		})
 	})
 
+	It("does not reuse the metric after Notify", func() {
+		_, metric := gobrake.NewRouteMetric(context.TODO(), "GET", "/ping")
+		metric.StatusCode = http.StatusOK
+		err := notifier.Routes.Notify(context.TODO(), metric)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, other := gobrake.NewRouteMetric(context.TODO(), "POST", "/pong")
+		Expect(other).NotTo(BeIdenticalTo(metric))
+		Expect(metric.Route).To(Equal("/ping"))
+		Expect(metric.StatusCode).To(Equal(http.StatusOK))
+	})
+
 	It("ignores route stat with route is /pong", func() {
 		_, metric := gobrake.NewRouteMetric(context.TODO(), "GET", "/pong")
 		metric.StatusCode = http.StatusOK
@@ -648,6 +1095,98 @@ var _ = Describe("Notifier request filter", func() {
 			Expect(route.Count).To(Equal(1))
 		})
 	})
+
+	Context("when EnableCompression is enabled", func() {
+		BeforeEach(func() {
+			opt.EnableCompression = true
+		})
+
+		It("sends gzip-compressed route stat", func() {
+			_, metric := gobrake.NewRouteMetric(context.TODO(), "GET", "/ping")
+			metric.StatusCode = http.StatusOK
+			err := notifier.Routes.Notify(context.TODO(), metric)
+			Expect(err).NotTo(HaveOccurred())
+
+			notifier.Routes.Flush()
+			Expect(stats.Routes).To(HaveLen(1))
+			Expect(stats.Routes[0].Route).To(Equal("/ping"))
+		})
+	})
+
+	Context("when APMMaxKeys is set", func() {
+		BeforeEach(func() {
+			opt.APMMaxKeys = 2
+		})
+
+		It("aggregates routes beyond the limit under other", func() {
+			for _, route := range []string{"/a", "/b", "/c", "/d", "/a"} {
+				_, metric := gobrake.NewRouteMetric(context.TODO(), "GET", route)
+				metric.StatusCode = http.StatusOK
+				err := notifier.Routes.Notify(context.TODO(), metric)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			notifier.Routes.Flush()
+
+			counts := make(map[string]int)
+			for _, route := range stats.Routes {
+				counts[route.Route] = route.Count
+			}
+			Expect(counts).To(Equal(map[string]int{
+				"/a":    2,
+				"/b":    1,
+				"other": 2,
+			}))
+		})
+	})
+
+	notifyRoutes := func(routes ...string) map[string]int {
+		for _, route := range routes {
+			_, metric := gobrake.NewRouteMetric(context.TODO(), "GET", route)
+			metric.StatusCode = http.StatusOK
+			err := notifier.Routes.Notify(context.TODO(), metric)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		notifier.Routes.Flush()
+
+		counts := make(map[string]int)
+		for _, route := range stats.Routes {
+			counts[route.Route] = route.Count
+		}
+		return counts
+	}
+
+	It("reports routes as is", func() {
+		Expect(notifyRoutes("/users/123")).To(Equal(map[string]int{
+			"/users/123": 1,
+		}))
+	})
+
+	Context("when RouteTemplates is set", func() {
+		BeforeEach(func() {
+			opt.RouteTemplates = []string{"/users/:name"}
+		})
+
+		It("reports the matching template", func() {
+			Expect(notifyRoutes("/users/alice", "/users/bob", "/posts/1")).To(Equal(map[string]int{
+				"/users/:name": 2,
+				"/posts/1":     1,
+			}))
+		})
+	})
+
+	Context("when EnableRouteNormalization is enabled", func() {
+		BeforeEach(func() {
+			opt.EnableRouteNormalization = true
+		})
+
+		It("normalizes routes", func() {
+			Expect(notifyRoutes("/users/123", "/users/456")).To(Equal(map[string]int{
+				"/users/:id": 2,
+			}))
+		})
+	})
 })
 
 var _ = Describe("(*NotifierOptions).Copy()", func() {
@@ -723,4 +1262,10 @@ var _ = Describe("(*NotifierOptions).Copy()", func() {
 		copy.DisableAPM = false
 		Expect(opt.DisableAPM).To(BeTrue())
 	})
+
+	It("copies EnableCompression", func() {
+		opt.EnableCompression = true
+		copy := opt.Copy()
+		Expect(copy.EnableCompression).To(BeTrue())
+	})
 })
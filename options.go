@@ -0,0 +1,74 @@
+package gobrake
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// sharedOptions holds the current notifier options. Options stored in it
+// are never modified; updates store a modified copy, so readers can use
+// the loaded options without locking.
+type sharedOptions struct {
+	v atomic.Value // *NotifierOptions
+
+	mu sync.Mutex
+	// Options set by the application.
+	local *NotifierOptions
+	// Applies the remote config to the copy of local options.
+	applyRemote func(opt, local *NotifierOptions)
+
+	// *routeNormalizer of the current options.
+	normalizer atomic.Value
+}
+
+func newSharedOptions(opt *NotifierOptions) *sharedOptions {
+	o := &sharedOptions{
+		local: opt.Copy(),
+	}
+	o.v.Store(opt.Copy())
+	return o
+}
+
+// Load returns the current options. The returned options must not be
+// modified.
+func (o *sharedOptions) Load() *NotifierOptions {
+	return o.v.Load().(*NotifierOptions)
+}
+
+// Update changes local options with fn and stores the new options with the
+// remote config applied. fn can be nil to only reapply the remote config.
+func (o *sharedOptions) Update(fn func(*NotifierOptions)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if fn != nil {
+		local := o.local.Copy()
+		fn(local)
+		o.local = local
+	}
+
+	opt := o.local.Copy()
+	if o.applyRemote != nil {
+		o.applyRemote(opt, o.local)
+	}
+	o.v.Store(opt)
+}
+
+func (o *sharedOptions) setApplyRemote(fn func(opt, local *NotifierOptions)) {
+	o.mu.Lock()
+	o.applyRemote = fn
+	o.mu.Unlock()
+}
+
+// routeNormalizer returns the normalizer of the current options. Routes of
+// route stats, route breakdowns and query stats are normalized with it, so
+// they are reported under the same keys.
+func (o *sharedOptions) routeNormalizer() *routeNormalizer {
+	opt := o.Load()
+	if n, _ := o.normalizer.Load().(*routeNormalizer); n != nil && n.opt == opt {
+		return n
+	}
+	n := newRouteNormalizer(opt)
+	o.normalizer.Store(n)
+	return n
+}
@@ -0,0 +1,128 @@
+package gobrake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PingError is returned by Notifier.Ping when a host can't be reached or
+// rejects the project id or key.
+type PingError struct {
+	// URL of the failed request.
+	URL string
+	Err error
+}
+
+func (e *PingError) Error() string {
+	return fmt.Sprintf("gobrake: ping %s failed: %s", e.URL, e.Err)
+}
+
+func (e *PingError) Unwrap() error {
+	return e.Err
+}
+
+// Ping validates the options and checks that the error and APM hosts of
+// every destination accept the project id and key and that the remote
+// config can be fetched. Checks of disabled features are skipped. Ping is
+// meant to be called on start, so deploys with a wrong configuration fail
+// fast. The checks send empty payloads that Airbrake rejects, so they don't
+// create notices or stats.
+func (n *Notifier) Ping(c context.Context) error {
+	opt := n.opts.Load()
+	if err := opt.Validate(); err != nil {
+		return err
+	}
+
+	for _, d := range n.destinations {
+		dopt := d.options()
+
+		if !opt.DisableErrorNotifications {
+			url := fmt.Sprintf("%s/api/v3/projects/%d/notices",
+				dopt.Host, dopt.ProjectId)
+			err := ping(c, dopt, http.MethodPost, url, `{"errors":[]}`)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !opt.DisableAPM {
+			url := fmt.Sprintf("%s/api/v5/projects/%d/routes-stats",
+				dopt.APMHost, dopt.ProjectId)
+			err := ping(c, dopt, http.MethodPut, url, `{"routes":[]}`)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if !opt.DisableRemoteConfig {
+		url := fmt.Sprintf(configRoutePattern,
+			strings.TrimSuffix(opt.RemoteConfigHost, "/"),
+			apiVer, opt.ProjectId)
+		if err := pingRemoteConfig(c, opt, url); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ping sends body to url and checks that the project id and key are
+// accepted. Airbrake checks the credentials first and then rejects the empty
+// payload with 400 Bad Request, so 400 and 2xx mean that the host accepts
+// the credentials. Any other status is reported.
+func ping(c context.Context, opt *NotifierOptions, method, url, body string) error {
+	req, err := newRequest(opt, method, url, bytes.NewBufferString(body))
+	if err != nil {
+		return &PingError{URL: url, Err: err}
+	}
+
+	resp, err := opt.HTTPClient.Do(req.WithContext(c))
+	if err != nil {
+		return &PingError{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest,
+		resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized,
+		resp.StatusCode == http.StatusForbidden:
+		return &PingError{URL: url, Err: errUnauthorized}
+	}
+	return &PingError{
+		URL: url,
+		Err: fmt.Errorf("got unexpected response status=%q", resp.Status),
+	}
+}
+
+// pingRemoteConfig checks that the remote config host can be reached.
+// Projects without a published config get 403 or 404, which the notifier
+// treats as the default config, so they are not reported either.
+
+func pingRemoteConfig(c context.Context, opt *NotifierOptions, url string) error {
+	req, err := buildRequest(url)
+	if err != nil {
+		return &PingError{URL: url, Err: err}
+	}
+
+	resp, err := opt.HTTPClient.Do(req.WithContext(c))
+	if err != nil {
+		return &PingError{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNotModified,
+		http.StatusForbidden, http.StatusNotFound:
+		return nil
+	}
+	return &PingError{
+		URL: url,
+		Err: fmt.Errorf("got unexpected response status=%q", resp.Status),
+	}
+}
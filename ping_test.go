@@ -0,0 +1,151 @@
+package gobrake_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/airbrake/gobrake/v5"
+)
+
+var _ = Describe("Notifier.Ping", func() {
+	var notifier *gobrake.Notifier
+	var opt *gobrake.NotifierOptions
+	var requests []string
+	var status, configStatus int
+
+	BeforeEach(func() {
+		requests = nil
+		// Airbrake rejects empty payloads after checking the credentials.
+		status = http.StatusBadRequest
+		configStatus = http.StatusOK
+
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			body, err := io.ReadAll(req.Body)
+			Expect(err).NotTo(HaveOccurred())
+			requests = append(requests, req.Method+" "+req.URL.Path+" "+string(body))
+			if req.Header.Get("Authorization") != "Bearer key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(status)
+		}
+		server := httptest.NewServer(http.HandlerFunc(handler))
+		DeferCleanup(server.Close)
+
+		configHandler := func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(configStatus)
+		}
+		configServer := httptest.NewServer(http.HandlerFunc(configHandler))
+		DeferCleanup(configServer.Close)
+
+		opt = &gobrake.NotifierOptions{
+			ProjectId:        1,
+			ProjectKey:       "key",
+			Host:             server.URL,
+			RemoteConfigHost: configServer.URL,
+		}
+	})
+
+	JustBeforeEach(func() {
+		notifier = gobrake.NewNotifierWithOptions(opt)
+	})
+
+	AfterEach(func() {
+		Expect(notifier.Close()).NotTo(HaveOccurred())
+	})
+
+	It("checks error and APM hosts with empty payloads", func() {
+		Expect(notifier.Ping(context.Background())).To(Succeed())
+		Expect(requests).To(Equal([]string{
+			`POST /api/v3/projects/1/notices {"errors":[]}`,
+			`PUT /api/v5/projects/1/routes-stats {"routes":[]}`,
+		}))
+	})
+
+	DescribeTable("status of error and APM hosts",
+		func(code int, ok bool) {
+			status = code
+			err := notifier.Ping(context.Background())
+			if ok {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			var pingErr *gobrake.PingError
+			Expect(errors.As(err, &pingErr)).To(BeTrue())
+		},
+		Entry("201 Created", http.StatusCreated, true),
+		Entry("400 Bad Request", http.StatusBadRequest, true),
+		Entry("403 Forbidden", http.StatusForbidden, false),
+		Entry("404 Not Found", http.StatusNotFound, false),
+		Entry("405 Method Not Allowed", http.StatusMethodNotAllowed, false),
+		Entry("429 Too Many Requests", http.StatusTooManyRequests, false),
+		Entry("500 Internal Server Error", http.StatusInternalServerError, false),
+	)
+
+	DescribeTable("status of remote config host",
+		func(code int, ok bool) {
+			configStatus = code
+			err := notifier.Ping(context.Background())
+			if ok {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			var pingErr *gobrake.PingError
+			Expect(errors.As(err, &pingErr)).To(BeTrue())
+			Expect(pingErr.URL).To(ContainSubstring("/config/1/config.json"))
+		},
+		Entry("200 OK", http.StatusOK, true),
+		Entry("403 Forbidden", http.StatusForbidden, true),
+		Entry("404 Not Found", http.StatusNotFound, true),
+		Entry("500 Internal Server Error", http.StatusInternalServerError, false),
+	)
+
+	Context("when project key is invalid", func() {
+		BeforeEach(func() {
+			opt.ProjectKey = "invalid"
+		})
+
+		It("returns PingError", func() {
+			err := notifier.Ping(context.Background())
+
+			var pingErr *gobrake.PingError
+			Expect(errors.As(err, &pingErr)).To(BeTrue())
+			Expect(pingErr.URL).To(HaveSuffix("/api/v3/projects/1/notices"))
+			Expect(err).To(MatchError(ContainSubstring("invalid project id or key")))
+		})
+	})
+
+	Context("when options are invalid", func() {
+		BeforeEach(func() {
+			opt.ProjectId = 0
+		})
+
+		It("returns ValidationErrors without sending requests", func() {
+			err := notifier.Ping(context.Background())
+
+			var errs gobrake.ValidationErrors
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			Expect(requests).To(BeEmpty())
+		})
+	})
+
+	Context("when remote config host is unreachable", func() {
+		BeforeEach(func() {
+			opt.RemoteConfigHost = "http://localhost:1"
+		})
+
+		It("returns PingError", func() {
+			err := notifier.Ping(context.Background())
+
+			var pingErr *gobrake.PingError
+			Expect(errors.As(err, &pingErr)).To(BeTrue())
+			Expect(pingErr.URL).To(HavePrefix("http://localhost:1/"))
+		})
+	})
+})
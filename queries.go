@@ -1,13 +1,8 @@
 package gobrake
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"net/http"
-	"sync"
 	"time"
 )
 
@@ -38,44 +33,41 @@ type queryKeyStat struct {
 }
 
 type queryStats struct {
-	opt        *NotifierOptions
-	flushTimer *time.Timer
-	addWG      *sync.WaitGroup
-
-	mu sync.Mutex
-	m  map[queryKey]*tdigestStat
+	opts        *sharedOptions
+	dests       destinations
+	breadcrumbs *Breadcrumbs
+	agg         *aggregator
 }
 
-func newQueryStats(opt *NotifierOptions) *queryStats {
-	return &queryStats{
-		opt: opt,
+type queryStatsAggregate map[queryKey]*tdigestStat
+
+func (m queryStatsAggregate) merge(other aggregate) error {
+	for k, o := range other.(queryStatsAggregate) {
+		stat, ok := m[k]
+		if !ok {
+			m[k] = o
+			continue
+		}
+		if err := stat.merge(o); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (s *queryStats) init() {
-	if s.flushTimer == nil {
-		s.flushTimer = time.AfterFunc(flushPeriod, s.flush)
-		s.addWG = new(sync.WaitGroup)
-		s.m = make(map[queryKey]*tdigestStat)
+func newQueryStats(opts *sharedOptions, dests destinations, sched *apmScheduler) *queryStats {
+	s := &queryStats{
+		opts:  opts,
+		dests: dests,
 	}
+	s.agg = sched.newAggregator("queryStats", func() aggregate {
+		return make(queryStatsAggregate)
+	}, s.send)
+	return s
 }
 
 func (s *queryStats) flush() {
-	s.mu.Lock()
-
-	s.flushTimer = nil
-	addWG := s.addWG
-	s.addWG = nil
-	m := s.m
-	s.m = nil
-
-	s.mu.Unlock()
-
-	addWG.Wait()
-	err := s.send(m)
-	if err != nil {
-		logger.Printf("queryStats.send failed: %s", err)
-	}
+	s.agg.flush()
 }
 
 type queriesOut struct {
@@ -83,9 +75,9 @@ type queriesOut struct {
 	Queries []queryKeyStat `json:"queries"`
 }
 
-func (s *queryStats) send(m map[queryKey]*tdigestStat) error {
+func (s *queryStats) send(agg aggregate) error {
 	var queries []queryKeyStat
-	for k, v := range m {
+	for k, v := range agg.(queryStatsAggregate) {
 		err := v.Pack()
 		if err != nil {
 			return err
@@ -97,68 +89,18 @@ func (s *queryStats) send(m map[queryKey]*tdigestStat) error {
 		})
 	}
 
-	buf := buffers.Get().(*bytes.Buffer)
-	defer buffers.Put(buf)
-	buf.Reset()
-
 	out := queriesOut{
-		Env:     s.opt.Environment,
+		Env:     s.opts.Load().Environment,
 		Queries: queries,
 	}
-	err := json.NewEncoder(buf).Encode(&out)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest(
-		http.MethodPut,
-		fmt.Sprintf("%s/api/v5/projects/%d/queries-stats",
-			s.opt.APMHost, s.opt.ProjectId),
-		buf,
-	)
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+s.opt.ProjectKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-	resp, err := s.opt.HTTPClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	buf.Reset()
-	_, err = buf.ReadFrom(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return nil
-	}
-
-	switch resp.StatusCode {
-	case http.StatusUnauthorized:
-		return errUnauthorized
-	case http.StatusBadRequest, http.StatusTooManyRequests:
-		var sendResp sendResponse
-		err = json.NewDecoder(buf).Decode(&sendResp)
-		if err != nil {
-			return err
-		}
-		return errors.New(sendResp.Message)
-	case 404, 408, 409, 410, 500, 502, 504:
-		setQueryBacklog(out)
-	}
-
-	err = fmt.Errorf("got unexpected response status=%q", resp.Status)
-	return err
+	return s.dests.sendAPM("queries-stats", out)
 }
 
+// Notify adds the query to query stats. Its route is reported under the same
+// key as in route stats.
 func (s *queryStats) Notify(c context.Context, q *QueryInfo) error {
-	if s.opt.DisableAPM {
+	opt := s.opts.Load()
+	if opt.DisableAPM {
 		return fmt.Errorf(
 			"APM is disabled, query is not sent: %s (%s:%d)",
 			q.Query, q.File, q.Line,
@@ -167,28 +109,51 @@ func (s *queryStats) Notify(c context.Context, q *QueryInfo) error {
 
 	key := queryKey{
 		Method: q.Method,
-		Route:  q.Route,
+		Route:  s.opts.routeNormalizer().normalize(q.Route),
 		Query:  q.Query,
 		Func:   q.Func,
 		File:   q.File,
 		Line:   q.Line,
-		Time:   q.StartTime.UTC().Truncate(time.Minute),
+		Time:   bucketTime(q.StartTime, opt.apmBucketResolution()),
 	}
+	dur := q.EndTime.Sub(q.StartTime)
 
-	s.mu.Lock()
-	s.init()
-	stat, ok := s.m[key]
-	if !ok {
-		stat = newTDigestStat()
-		s.m[key] = stat
-	}
-	addWG := s.addWG
-	s.addWG.Add(1)
-	s.mu.Unlock()
+	err := s.agg.add(func(agg aggregate) error {
+		m := agg.(queryStatsAggregate)
+		k := key
+		stat, ok := m[k]
+		if !ok && !s.agg.admit(k.Query, opt.APMMaxKeys) {
+			// Routes and call sites of other queries are dropped too, so
+			// they don't add keys.
+			k = queryKey{
+				Query: otherKey,
+				Time:  k.Time,
+			}
+			stat, ok = m[k]
+		}
+		if !ok {
+			stat = newTDigestStat()
+			m[k] = stat
+		}
+		return stat.add(dur)
+	})
 
-	dur := q.EndTime.Sub(q.StartTime)
-	err := stat.Add(dur)
-	addWG.Done()
+	s.addBreadcrumb(c, q, dur)
 
 	return err
 }
+
+func (s *queryStats) addBreadcrumb(c context.Context, q *QueryInfo, dur time.Duration) {
+	b := ContextBreadcrumbs(c)
+	if b == nil {
+		b = s.breadcrumbs
+	}
+	b.Add(Breadcrumb{
+		Timestamp: q.StartTime,
+		Category:  BreadcrumbQuery,
+		Message:   q.Query,
+		Data: map[string]interface{}{
+			"durationMs": durInMs(dur),
+		},
+	})
+}
@@ -9,7 +9,8 @@ type QueueMetric struct {
 	Queue   string
 	Errored bool
 
-	root Span
+	root     Span
+	rootSpan span
 }
 
 var _ Metric = (*QueueMetric)(nil)
@@ -19,10 +20,8 @@ func NewQueueMetric(c context.Context, name string) (context.Context, *QueueMetr
 		Queue: name,
 	}
 	t.metric.init()
-	if c != nil {
-		c = withMetric(c, t)
-	}
-	c, t.root = t.Start(c, "queue.handler")
+	c = t.metric.startRoot(c, t, &t.rootSpan, "queue.handler")
+	t.root = &t.rootSpan
 	return c, t
 }
 
@@ -1,13 +1,8 @@
 package gobrake
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"net/http"
-	"sync"
 	"time"
 )
 
@@ -22,10 +17,7 @@ type queueBreakdown struct {
 	ErrorCount int `json:"errorCount"`
 }
 
-func (b *queueBreakdown) Add(total time.Duration, groups map[string]time.Duration, errored bool) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
+func (b *queueBreakdown) add(total time.Duration, groups map[string]time.Duration, errored bool) {
 	b.tdigestStatGroups.add(total, groups)
 	if errored {
 		b.ErrorCount++
@@ -33,44 +25,41 @@ func (b *queueBreakdown) Add(total time.Duration, groups map[string]time.Duratio
 }
 
 type queueStats struct {
-	opt        *NotifierOptions
-	flushTimer *time.Timer
-	addWG      *sync.WaitGroup
-
-	mu sync.Mutex
-	m  map[queueKey]*queueBreakdown
+	opts  *sharedOptions
+	dests destinations
+	agg   *aggregator
 }
 
-func newQueueStats(opt *NotifierOptions) *queueStats {
-	return &queueStats{
-		opt: opt,
+type queueStatsAggregate map[queueKey]*queueBreakdown
+
+func (m queueStatsAggregate) merge(other aggregate) error {
+	for k, o := range other.(queueStatsAggregate) {
+		b, ok := m[k]
+		if !ok {
+			m[k] = o
+			continue
+		}
+		if err := b.merge(&o.tdigestStatGroups); err != nil {
+			return err
+		}
+		b.ErrorCount += o.ErrorCount
 	}
+	return nil
 }
 
-func (s *queueStats) init() {
-	if s.flushTimer == nil {
-		s.flushTimer = time.AfterFunc(flushPeriod, s.flush)
-		s.addWG = new(sync.WaitGroup)
-		s.m = make(map[queueKey]*queueBreakdown)
+func newQueueStats(opts *sharedOptions, dests destinations, sched *apmScheduler) *queueStats {
+	s := &queueStats{
+		opts:  opts,
+		dests: dests,
 	}
+	s.agg = sched.newAggregator("queueStats", func() aggregate {
+		return make(queueStatsAggregate)
+	}, s.send)
+	return s
 }
 
 func (s *queueStats) flush() {
-	s.mu.Lock()
-
-	s.flushTimer = nil
-	addWG := s.addWG
-	s.addWG = nil
-	m := s.m
-	s.m = nil
-
-	s.mu.Unlock()
-
-	addWG.Wait()
-	err := s.send(m)
-	if err != nil {
-		logger.Printf("queueStats.send failed: %s", err)
-	}
+	s.agg.flush()
 }
 
 type queuesOut struct {
@@ -78,9 +67,9 @@ type queuesOut struct {
 	Queues []*queueBreakdown `json:"queues"`
 }
 
-func (s *queueStats) send(m map[queueKey]*queueBreakdown) error {
+func (s *queueStats) send(agg aggregate) error {
 	var queues []*queueBreakdown
-	for _, v := range m {
+	for _, v := range agg.(queueStatsAggregate) {
 		err := v.Pack()
 		if err != nil {
 			return err
@@ -88,74 +77,25 @@ func (s *queueStats) send(m map[queueKey]*queueBreakdown) error {
 		queues = append(queues, v)
 	}
 
-	buf := buffers.Get().(*bytes.Buffer)
-	defer buffers.Put(buf)
-	buf.Reset()
-
 	out := queuesOut{
-		Env:    s.opt.Environment,
+		Env:    s.opts.Load().Environment,
 		Queues: queues,
 	}
-	err := json.NewEncoder(buf).Encode(&out)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest(
-		http.MethodPut,
-		fmt.Sprintf("%s/api/v5/projects/%d/queues-stats",
-			s.opt.APMHost, s.opt.ProjectId),
-		buf,
-	)
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+s.opt.ProjectKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-	resp, err := s.opt.HTTPClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	buf.Reset()
-	_, err = buf.ReadFrom(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return nil
-	}
-
-	switch resp.StatusCode {
-	case http.StatusUnauthorized:
-		return errUnauthorized
-	case http.StatusBadRequest, http.StatusTooManyRequests:
-		var sendResp sendResponse
-		err = json.NewDecoder(buf).Decode(&sendResp)
-		if err != nil {
-			return err
-		}
-		return errors.New(sendResp.Message)
-	case 404, 408, 409, 410, 500, 502, 504:
-		setQueueBacklog(out)
-	}
-
-	err = fmt.Errorf("got unexpected response status=%q", resp.Status)
-	return err
+	return s.dests.sendAPM("queues-stats", out)
 }
 
 func (s *queueStats) Notify(c context.Context, metric *QueueMetric) error {
-	if s.opt.DisableAPM {
+	opt := s.opts.Load()
+	if opt.DisableAPM {
 		return fmt.Errorf(
 			"APM is disabled, queue is not sent: %s", metric.Queue,
 		)
 	}
 
 	metric.finish()
+	if name := metric.refinishedSpan(); name != "" {
+		opt.log().Warn("span is already finished", "span", name)
+	}
 
 	total, err := metric.duration()
 	if err != nil {
@@ -164,25 +104,25 @@ func (s *queueStats) Notify(c context.Context, metric *QueueMetric) error {
 
 	key := queueKey{
 		Queue: metric.Queue,
-		Time:  metric.startTime.UTC().Truncate(time.Minute),
-	}
-
-	s.mu.Lock()
-	s.init()
-	b, ok := s.m[key]
-	if !ok {
-		b = &queueBreakdown{
-			queueKey: key,
-		}
-		s.m[key] = b
+		Time:  bucketTime(metric.startTime, opt.apmBucketResolution()),
 	}
-	addWG := s.addWG
-	s.addWG.Add(1)
-	s.mu.Unlock()
-
 	groups := metric.flushGroups()
-	b.Add(total, groups, metric.Errored)
-	addWG.Done()
 
-	return nil
+	return s.agg.add(func(agg aggregate) error {
+		m := agg.(queueStatsAggregate)
+		k := key
+		b, ok := m[k]
+		if !ok && !s.agg.admit(k.Queue, opt.APMMaxKeys) {
+			k.Queue = otherKey
+			b, ok = m[k]
+		}
+		if !ok {
+			b = &queueBreakdown{
+				queueKey: k,
+			}
+			m[k] = b
+		}
+		b.add(total, groups, metric.Errored)
+		return nil
+	})
 }
@@ -6,8 +6,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -22,21 +27,42 @@ const configRoutePattern = "%s/%s/config/%d/config.json"
 
 // Setting names in JSON returned by the API.
 const (
-	errorsSetting = "errors"
-	apmSetting    = "apm"
+	errorsSetting         = "errors"
+	apmSetting            = "apm"
+	samplingRateSetting   = "sampling_rate"
+	keysBlocklistSetting  = "keys_blocklist"
+	ignoredErrorsSetting  = "ignored_errors"
+	ignoredMessageSetting = "ignored_messages"
+	codeHunksSetting      = "code_hunks"
+	backlogSetting        = "backlog"
+	apmFlushPeriodSetting = "apm_flush_period"
 )
 
 type remoteConfig struct {
-	opt *NotifierOptions
-	// opt copy to capture the initial state of the local config.
-	origOpt *NotifierOptions
+	opts *sharedOptions
 
-	ticker   *time.Ticker
-	pollStop chan bool
+	pollStop     chan struct{}
+	pollDone     chan struct{}
+	pollStopOnce sync.Once
+
+	// Validators of the last fetched config used for conditional requests.
+	etag         string
+	lastModified string
+
+	// mu guards JSON replacement and listeners.
+	mu        sync.Mutex
+	listeners []func(old, new RemoteConfigJSON)
 
 	JSON *RemoteConfigJSON
 }
 
+// remoteConfigCache is stored in NotifierOptions.RemoteConfigCacheFile.
+type remoteConfigCache struct {
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	Config       json.RawMessage `json:"config"`
+}
+
 type RemoteConfigJSON struct {
 	ProjectId   int64  `json:"project_id"`
 	UpdatedAt   int64  `json:"updated_at"`
@@ -50,100 +76,291 @@ type RemoteSettings struct {
 	Name     string `json:"name"`
 	Enabled  bool   `json:"enabled"`
 	Endpoint string `json:"endpoint"`
+
+	// Value of numeric settings such as sampling_rate and apm_flush_period
+	// (in seconds).
+	Value float64 `json:"value,omitempty"`
+	// Values of list settings such as keys_blocklist.
+	Values []string `json:"values,omitempty"`
 }
 
-func newRemoteConfig(opt *NotifierOptions) *remoteConfig {
-	return &remoteConfig{
-		opt:     opt,
-		origOpt: opt.Copy(),
+func newRemoteConfig(opts *sharedOptions) *remoteConfig {
+	rc := &remoteConfig{
+		opts: opts,
 
 		JSON: &RemoteConfigJSON{},
 	}
+	opts.setApplyRemote(rc.apply)
+	return rc
 }
 
 func (rc *remoteConfig) Poll() {
-	rc.pollStop = make(chan bool)
+	rc.pollStop = make(chan struct{})
+	rc.pollDone = make(chan struct{})
+
+	if err := rc.loadCache(); err != nil {
+		rc.opts.Load().log().Warn("loadCache failed", "error", err)
+	}
 
 	go func() {
+		defer close(rc.pollDone)
+
 		rc.updateLocalConfig()
 
 		if err := rc.tick(); err != nil {
-			logger.Print(err)
+			rc.opts.Load().log().Error("remote config update failed", "error", err)
 		}
 		rc.updateLocalConfig()
 
-		rc.ticker = time.NewTicker(rc.Interval())
+		ticker := time.NewTicker(rc.Interval())
 
 		for {
-		sel:
 			select {
-			case <-rc.ticker.C:
+			case <-ticker.C:
 				if err := rc.tick(); err != nil {
-					logger.Print(err)
+					rc.opts.Load().log().Error("remote config update failed", "error", err)
 					continue
 				}
 
-				rc.ticker.Stop()
+				ticker.Stop()
 				rc.updateLocalConfig()
 
-				rc.ticker = time.NewTicker(rc.Interval())
+				ticker = time.NewTicker(rc.Interval())
 			case <-rc.pollStop:
-				break sel
+				ticker.Stop()
+				return
 			}
 		}
 	}()
 }
 
 func (rc *remoteConfig) tick() error {
-	route := rc.ConfigRoute(rc.opt.RemoteConfigHost)
+	opt := rc.opts.Load()
+	route := rc.ConfigRoute(opt.RemoteConfigHost)
+	if opt.Debug {
+		opt.log().Debug("fetching remote config", "url", route)
+	}
 	body, err := rc.fetchConfig(route)
 	if err != nil {
 		return fmt.Errorf(
 			"fetchConfig failed for %s. Reason: %s", route, err,
 		)
 	}
-	if err = json.Unmarshal(body, rc.JSON); err != nil {
+	if body == nil {
+		// Not modified.
+		return nil
+	}
+
+	cfg := new(RemoteConfigJSON)
+	if err = json.Unmarshal(body, cfg); err != nil {
 		return fmt.Errorf("parseConfig failed: %s", err)
 	}
+	rc.setJSON(cfg)
+
+	if err := rc.saveCache(body); err != nil {
+		rc.opts.Load().log().Warn("saveCache failed", "error", err)
+	}
 
 	return nil
 }
 
+// setJSON replaces the remote config, applies it to the local config and
+// calls the listeners when the config has changed.
+func (rc *remoteConfig) setJSON(cfg *RemoteConfigJSON) {
+	rc.mu.Lock()
+	old := rc.JSON
+	rc.JSON = cfg
+	listeners := rc.listeners
+	rc.mu.Unlock()
+
+	if reflect.DeepEqual(old, cfg) {
+		return
+	}
+
+	rc.updateLocalConfig()
+	for _, fn := range listeners {
+		fn(*old, *cfg)
+	}
+}
+
+// OnChange registers fn that is called with the old and the new config
+// every time a changed config is fetched.
+func (rc *remoteConfig) OnChange(fn func(old, new RemoteConfigJSON)) {
+	rc.mu.Lock()
+	rc.listeners = append(rc.listeners, fn)
+	rc.mu.Unlock()
+}
+
+// Config returns a copy of the current remote config.
+func (rc *remoteConfig) Config() RemoteConfigJSON {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return *rc.JSON
+}
+
+// loadCache loads the last fetched config from the cache file.
+func (rc *remoteConfig) loadCache() error {
+	file := rc.opts.Load().RemoteConfigCacheFile
+	if file == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var cache remoteConfigCache
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return err
+	}
+	cfg := new(RemoteConfigJSON)
+	if err := json.Unmarshal(cache.Config, cfg); err != nil {
+		return err
+	}
+
+	rc.etag = cache.ETag
+	rc.lastModified = cache.LastModified
+	rc.setJSON(cfg)
+	return nil
+}
+
+// saveCache stores the fetched config in the cache file.
+func (rc *remoteConfig) saveCache(body []byte) error {
+	file := rc.opts.Load().RemoteConfigCacheFile
+	if file == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(remoteConfigCache{
+		ETag:         rc.etag,
+		LastModified: rc.lastModified,
+		Config:       body,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
 func (rc *remoteConfig) updateLocalConfig() {
+	rc.opts.Update(nil)
+}
+
+// apply applies the remote config to opt, a copy of local options.
+func (rc *remoteConfig) apply(opt, local *NotifierOptions) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
 	if rc.ErrorHost() != "" {
-		rc.opt.Host = rc.ErrorHost()
+		opt.Host = rc.ErrorHost()
 	}
 
 	if rc.APMHost() != "" {
-		rc.opt.APMHost = rc.APMHost()
+		opt.APMHost = rc.APMHost()
 	}
 
-	rc.updateErrorNotifications()
-	rc.updateAPM()
+	rc.updateErrorNotifications(opt, local)
+	rc.updateAPM(opt, local)
+	rc.updateCodeHunks(opt, local)
+	rc.updateBacklog(opt, local)
+	rc.updateSamplingRate(opt, local)
+	rc.updateAPMFlushPeriod(opt, local)
+	rc.updateKeysBlocklist(opt, local)
+	rc.updateIgnoredErrors(opt, local)
 }
 
-func (rc *remoteConfig) updateErrorNotifications() {
-	if rc.origOpt.DisableErrorNotifications {
+func (rc *remoteConfig) updateErrorNotifications(opt, local *NotifierOptions) {
+	if local.DisableErrorNotifications {
 		return
 	}
 
-	rc.opt.DisableErrorNotifications = !rc.ErrorNotifications()
+	opt.DisableErrorNotifications = !rc.ErrorNotifications()
 }
 
-func (rc *remoteConfig) updateAPM() {
-	if rc.origOpt.DisableAPM {
+func (rc *remoteConfig) updateAPM(opt, local *NotifierOptions) {
+	if local.DisableAPM {
 		return
 	}
 
-	rc.opt.DisableAPM = !rc.APM()
+	opt.DisableAPM = !rc.APM()
 }
 
-func (rc *remoteConfig) StopPolling() {
-	if rc.ticker != nil {
-		rc.ticker.Stop()
+func (rc *remoteConfig) updateCodeHunks(opt, local *NotifierOptions) {
+	if local.DisableCodeHunks {
+		return
 	}
+
+	opt.DisableCodeHunks = !rc.CodeHunks()
+}
+
+func (rc *remoteConfig) updateBacklog(opt, local *NotifierOptions) {
+	if local.DisableBacklog {
+		return
+	}
+
+	opt.DisableBacklog = !rc.Backlog()
+}
+
+func (rc *remoteConfig) updateSamplingRate(opt, local *NotifierOptions) {
+	if local.SamplingRate != 0 {
+		return
+	}
+
+	opt.SamplingRate = rc.SamplingRate()
+}
+
+func (rc *remoteConfig) updateAPMFlushPeriod(opt, local *NotifierOptions) {
+	if local.APMFlushPeriod != 0 {
+		return
+	}
+
+	opt.APMFlushPeriod = rc.APMFlushPeriod()
+}
+
+// updateKeysBlocklist appends keys from the remote config to the local
+// keys blocklist. Keys are regular expressions.
+func (rc *remoteConfig) updateKeysBlocklist(opt, local *NotifierOptions) {
+	keys := local.KeysBlocklist
+	if remote := rc.KeysBlocklist(); len(remote) > 0 {
+		keys = append(keys[:len(keys):len(keys)], remote...)
+	}
+	opt.KeysBlocklist = keys
+}
+
+// updateIgnoredErrors appends ignored error types and messages from the
+// remote config to the local ones.
+func (rc *remoteConfig) updateIgnoredErrors(opt, local *NotifierOptions) {
+	opt.IgnoredErrorTypes = appendStrings(
+		local.IgnoredErrorTypes, rc.settingValues(ignoredErrorsSetting))
+	opt.IgnoredErrorMessages = appendStrings(
+		local.IgnoredErrorMessages, rc.settingValues(ignoredMessageSetting))
+}
+
+func appendStrings(local, remote []string) []string {
+	if len(remote) == 0 {
+		return local
+	}
+	return append(local[:len(local):len(local)], remote...)
+}
+
+func (rc *remoteConfig) StopPolling() {
 	if rc.pollStop != nil {
-		rc.pollStop <- true
+		rc.pollStopOnce.Do(func() {
+			close(rc.pollStop)
+		})
+		<-rc.pollDone
 	}
 }
 
@@ -164,7 +381,7 @@ func (rc *remoteConfig) ConfigRoute(remoteConfigHost string) string {
 
 	return fmt.Sprintf(configRoutePattern,
 		strings.TrimSuffix(remoteConfigHost, "/"),
-		apiVer, rc.opt.ProjectId)
+		apiVer, rc.opts.Load().ProjectId)
 }
 
 func (rc *remoteConfig) ErrorNotifications() bool {
@@ -187,6 +404,73 @@ func (rc *remoteConfig) APM() bool {
 	return true
 }
 
+func (rc *remoteConfig) CodeHunks() bool {
+	if s := rc.setting(codeHunksSetting); s != nil {
+		return s.Enabled
+	}
+
+	return true
+}
+
+func (rc *remoteConfig) Backlog() bool {
+	if s := rc.setting(backlogSetting); s != nil {
+		return s.Enabled
+	}
+
+	return true
+}
+
+// SamplingRate returns the sampling rate from the remote config or 0 when
+// it's not set or invalid.
+func (rc *remoteConfig) SamplingRate() float64 {
+	if s := rc.setting(samplingRateSetting); s != nil && s.Value > 0 && s.Value <= 1 {
+		return s.Value
+	}
+
+	return 0
+}
+
+func (rc *remoteConfig) APMFlushPeriod() time.Duration {
+	if s := rc.setting(apmFlushPeriodSetting); s != nil && s.Value > 0 {
+		return time.Duration(s.Value * float64(time.Second))
+	}
+
+	return 0
+}
+
+// KeysBlocklist returns compiled keys from the remote config. Invalid
+// regular expressions are skipped.
+func (rc *remoteConfig) KeysBlocklist() []interface{} {
+	var keys []interface{}
+	for _, v := range rc.settingValues(keysBlocklistSetting) {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			rc.opts.Load().log().Warn("invalid remote keys blocklist entry", "entry", v, "error", err)
+			continue
+		}
+		keys = append(keys, re)
+	}
+	return keys
+}
+
+func (rc *remoteConfig) setting(name string) *RemoteSettings {
+	for _, s := range rc.JSON.RemoteSettings {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func (rc *remoteConfig) settingValues(name string) []string {
+	if s := rc.setting(name); s != nil {
+		return s.Values
+	}
+
+	return nil
+}
+
 func (rc *remoteConfig) ErrorHost() string {
 	for _, s := range rc.JSON.RemoteSettings {
 		if s.Name == errorsSetting {
@@ -207,13 +491,21 @@ func (rc *remoteConfig) APMHost() string {
 	return ""
 }
 
+// fetchConfig returns the config body or nil when the config is not
+// modified since the last fetch.
 func (rc *remoteConfig) fetchConfig(url string) ([]byte, error) {
 	req, err := buildRequest(url)
 	if err != nil {
 		return nil, err
 	}
+	if rc.etag != "" {
+		req.Header.Set("If-None-Match", rc.etag)
+	}
+	if rc.lastModified != "" {
+		req.Header.Set("If-Modified-Since", rc.lastModified)
+	}
 
-	resp, err := rc.opt.HTTPClient.Do(req)
+	resp, err := rc.opts.Load().HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +519,11 @@ func (rc *remoteConfig) fetchConfig(url string) ([]byte, error) {
 	switch resp.StatusCode {
 	case http.StatusForbidden, http.StatusNotFound:
 		return nil, errors.New(string(body))
+	case http.StatusNotModified:
+		return nil, nil
 	case http.StatusOK:
+		rc.etag = resp.Header.Get("ETag")
+		rc.lastModified = resp.Header.Get("Last-Modified")
 		return body, nil
 	default:
 		return nil, fmt.Errorf("unhandled status (%d): %s",
@@ -5,6 +5,8 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"regexp"
 	"runtime"
 	"time"
 
@@ -32,7 +34,7 @@ var _ = Describe("newRemoteConfig", func() {
 		})
 
 		JustBeforeEach(func() {
-			rc = newRemoteConfig(opt)
+			rc = newRemoteConfig(newSharedOptions(opt))
 		})
 
 		AfterEach(func() {
@@ -313,7 +315,7 @@ var _ = Describe("newRemoteConfig", func() {
 				It("keeps error notifications disabled", func() {
 					rc.Poll()
 					rc.StopPolling()
-					Expect(opt.DisableErrorNotifications).To(BeTrue())
+					Expect(rc.opts.Load().DisableErrorNotifications).To(BeTrue())
 				})
 			})
 
@@ -325,7 +327,7 @@ var _ = Describe("newRemoteConfig", func() {
 				It("enables error notifications", func() {
 					rc.Poll()
 					rc.StopPolling()
-					Expect(opt.DisableErrorNotifications).To(BeFalse())
+					Expect(rc.opts.Load().DisableErrorNotifications).To(BeFalse())
 				})
 			})
 		})
@@ -352,7 +354,7 @@ var _ = Describe("newRemoteConfig", func() {
 				It("keeps error notifications disabled", func() {
 					rc.Poll()
 					rc.StopPolling()
-					Expect(opt.DisableErrorNotifications).To(BeTrue())
+					Expect(rc.opts.Load().DisableErrorNotifications).To(BeTrue())
 				})
 			})
 
@@ -364,7 +366,7 @@ var _ = Describe("newRemoteConfig", func() {
 				It("disables error notifications", func() {
 					rc.Poll()
 					rc.StopPolling()
-					Expect(opt.DisableErrorNotifications).To(BeTrue())
+					Expect(rc.opts.Load().DisableErrorNotifications).To(BeTrue())
 				})
 			})
 		})
@@ -391,7 +393,7 @@ var _ = Describe("newRemoteConfig", func() {
 				It("keeps APM disabled", func() {
 					rc.Poll()
 					rc.StopPolling()
-					Expect(opt.DisableAPM).To(BeTrue())
+					Expect(rc.opts.Load().DisableAPM).To(BeTrue())
 				})
 			})
 
@@ -403,7 +405,7 @@ var _ = Describe("newRemoteConfig", func() {
 				It("enables APM", func() {
 					rc.Poll()
 					rc.StopPolling()
-					Expect(opt.DisableAPM).To(BeFalse())
+					Expect(rc.opts.Load().DisableAPM).To(BeFalse())
 				})
 			})
 		})
@@ -430,7 +432,7 @@ var _ = Describe("newRemoteConfig", func() {
 				It("keeps APM disabled", func() {
 					rc.Poll()
 					rc.StopPolling()
-					Expect(opt.DisableAPM).To(BeTrue())
+					Expect(rc.opts.Load().DisableAPM).To(BeTrue())
 				})
 			})
 
@@ -442,7 +444,7 @@ var _ = Describe("newRemoteConfig", func() {
 				It("disables APM", func() {
 					rc.Poll()
 					rc.StopPolling()
-					Expect(opt.DisableAPM).To(BeTrue())
+					Expect(rc.opts.Load().DisableAPM).To(BeTrue())
 				})
 			})
 		})
@@ -462,10 +464,10 @@ var _ = Describe("newRemoteConfig", func() {
 			})
 
 			It("changes config route", func() {
-				Expect(opt.Host).NotTo(Equal("http://foo.bar"))
+				Expect(rc.opts.Load().Host).NotTo(Equal("http://foo.bar"))
 				rc.Poll()
 				rc.StopPolling()
-				Expect(opt.Host).To(Equal("http://foo.bar"))
+				Expect(rc.opts.Load().Host).To(Equal("http://foo.bar"))
 			})
 		})
 
@@ -484,20 +486,154 @@ var _ = Describe("newRemoteConfig", func() {
 			})
 
 			It("changes config route", func() {
-				Expect(opt.APMHost).NotTo(Equal("http://foo.bar"))
+				Expect(rc.opts.Load().APMHost).NotTo(Equal("http://foo.bar"))
 				rc.Poll()
 				rc.StopPolling()
-				Expect(opt.APMHost).To(Equal("http://foo.bar"))
+				Expect(rc.opts.Load().APMHost).To(Equal("http://foo.bar"))
+			})
+		})
+		Context("when the remote config has other settings", func() {
+			var body = `{"settings":[
+				{"name":"sampling_rate","value":0.25},
+				{"name":"keys_blocklist","values":["token","(?i)auth"]},
+				{"name":"ignored_errors","values":["*url.Error"]},
+				{"name":"ignored_messages","values":["context canceled"]},
+				{"name":"code_hunks","enabled":false},
+				{"name":"backlog","enabled":false},
+				{"name":"apm_flush_period","value":60}
+			]}`
+
+			BeforeEach(func() {
+				handler := func(w http.ResponseWriter, req *http.Request) {
+					w.WriteHeader(http.StatusOK)
+					_, err := w.Write([]byte(body))
+					Expect(err).To(BeNil())
+				}
+				server := httptest.NewServer(http.HandlerFunc(handler))
+
+				opt.RemoteConfigHost = server.URL
+				opt.KeysBlocklist = []interface{}{"password"}
+				opt.IgnoredErrorTypes = []string{"*errors.errorString"}
+			})
+
+			It("applies the settings", func() {
+				rc.Poll()
+				rc.StopPolling()
+
+				Expect(rc.opts.Load().SamplingRate).To(Equal(0.25))
+				Expect(rc.opts.Load().KeysBlocklist).To(HaveLen(3))
+				Expect(rc.opts.Load().KeysBlocklist[0]).To(Equal("password"))
+				Expect(rc.opts.Load().KeysBlocklist[2].(*regexp.Regexp).String()).To(Equal("(?i)auth"))
+				Expect(rc.opts.Load().IgnoredErrorTypes).To(Equal([]string{"*errors.errorString", "*url.Error"}))
+				Expect(rc.opts.Load().IgnoredErrorMessages).To(Equal([]string{"context canceled"}))
+				Expect(rc.opts.Load().DisableCodeHunks).To(BeTrue())
+				Expect(rc.opts.Load().DisableBacklog).To(BeTrue())
+				Expect(rc.opts.Load().apmFlushPeriod()).To(Equal(time.Minute))
+			})
+
+			It("doesn't duplicate list settings on every poll", func() {
+				rc.Poll()
+				rc.StopPolling()
+				rc.updateLocalConfig()
+
+				Expect(rc.opts.Load().KeysBlocklist).To(HaveLen(3))
+				Expect(rc.opts.Load().IgnoredErrorTypes).To(HaveLen(2))
+			})
+
+			Context("and when the settings are set locally", func() {
+				BeforeEach(func() {
+					opt.SamplingRate = 1
+					opt.APMFlushPeriod = 5 * time.Second
+				})
+
+				It("keeps local settings", func() {
+					rc.Poll()
+					rc.StopPolling()
+
+					Expect(rc.opts.Load().SamplingRate).To(Equal(1.0))
+					Expect(rc.opts.Load().apmFlushPeriod()).To(Equal(5 * time.Second))
+				})
+			})
+		})
+		Context("when the server supports conditional requests", func() {
+			var reqs []*http.Request
+			var body = `{"poll_sec":5,"settings":[{"name":"errors","enabled":false}]}`
+
+			BeforeEach(func() {
+				reqs = nil
+				handler := func(w http.ResponseWriter, req *http.Request) {
+					reqs = append(reqs, req)
+					if req.Header.Get("If-None-Match") == `"v1"` {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+					w.Header().Set("ETag", `"v1"`)
+					w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+					w.WriteHeader(http.StatusOK)
+					_, err := w.Write([]byte(body))
+					Expect(err).To(BeNil())
+				}
+				server := httptest.NewServer(http.HandlerFunc(handler))
+
+				opt.RemoteConfigHost = server.URL
+				opt.RemoteConfigCacheFile = filepath.Join(GinkgoT().TempDir(), "config.json")
+			})
+
+			It("sends validators of the last config", func() {
+				rc.Poll()
+				rc.StopPolling()
+				Expect(rc.tick()).To(BeNil())
+
+				Expect(reqs).To(HaveLen(2))
+				Expect(reqs[1].Header.Get("If-None-Match")).To(Equal(`"v1"`))
+				Expect(reqs[1].Header.Get("If-Modified-Since")).To(Equal("Wed, 21 Oct 2015 07:28:00 GMT"))
+				Expect(rc.Interval()).To(Equal(5 * time.Second))
+				Expect(logBuf.String()).To(BeEmpty())
+			})
+
+			It("notifies listeners about changes", func() {
+				var calls []RemoteConfigJSON
+				rc.OnChange(func(old, new RemoteConfigJSON) {
+					Expect(old.PollSec).To(BeZero())
+					calls = append(calls, new)
+				})
+
+				rc.Poll()
+				rc.StopPolling()
+				Expect(rc.tick()).To(BeNil())
+
+				Expect(calls).To(HaveLen(1))
+				Expect(calls[0].PollSec).To(Equal(int64(5)))
+				Expect(rc.opts.Load().DisableErrorNotifications).To(BeTrue())
+			})
+
+			It("loads the cached config on start", func() {
+				rc.Poll()
+				rc.StopPolling()
+
+				cachedOpt := &NotifierOptions{
+					ProjectId:             1,
+					ProjectKey:            "key",
+					HTTPClient:            defaultHTTPClient(),
+					RemoteConfigHost:      "http://127.0.0.1:1",
+					RemoteConfigCacheFile: opt.RemoteConfigCacheFile,
+				}
+				cached := newRemoteConfig(newSharedOptions(cachedOpt))
+				Expect(cached.loadCache()).To(BeNil())
+
+				Expect(cached.Config().PollSec).To(Equal(int64(5)))
+				Expect(cached.etag).To(Equal(`"v1"`))
+				Expect(cached.opts.Load().DisableErrorNotifications).To(BeTrue())
 			})
 		})
 	})
 
 	Describe("Interval", func() {
 		BeforeEach(func() {
-			rc = newRemoteConfig(&NotifierOptions{
+			rc = newRemoteConfig(newSharedOptions(&NotifierOptions{
 				ProjectId:  1,
 				ProjectKey: "key",
-			})
+			}))
 		})
 
 		Context("when JSON PollSec is zero", func() {
@@ -533,10 +669,10 @@ var _ = Describe("newRemoteConfig", func() {
 
 	Describe("ConfigRoute", func() {
 		BeforeEach(func() {
-			rc = newRemoteConfig(&NotifierOptions{
+			rc = newRemoteConfig(newSharedOptions(&NotifierOptions{
 				ProjectId:  1,
 				ProjectKey: "key",
-			})
+			}))
 		})
 
 		Context("when JSON ConfigRoute is empty", func() {
@@ -0,0 +1,57 @@
+package gobrake
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"sync"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// newRequest creates Airbrake API request with JSON body taken from buf.
+// The body is gzip-compressed when opt.EnableCompression is set. Size limits
+// must be checked by the caller against the uncompressed buf.
+func newRequest(
+	opt *NotifierOptions, method, url string, buf *bytes.Buffer,
+) (*http.Request, error) {
+	body := buf
+	if opt.EnableCompression {
+		var err error
+		body, err = gzipCompress(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	setRequestHeaders(req, opt.ProjectKey)
+	if opt.EnableCompression {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req, nil
+}
+
+func gzipCompress(b []byte) (*bytes.Buffer, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(b)/4))
+
+	zw := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(zw)
+
+	zw.Reset(out)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
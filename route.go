@@ -5,16 +5,18 @@ import (
 )
 
 type routes struct {
+	opts    *sharedOptions
 	filters []routeFilter
 
 	stats      *routeStats
 	breakdowns *routeBreakdowns
 }
 
-func newRoutes(opt *NotifierOptions) *routes {
+func newRoutes(opts *sharedOptions, dests destinations, sched *apmScheduler) *routes {
 	return &routes{
-		stats:      newRouteStats(opt),
-		breakdowns: newRouteBreakdowns(opt),
+		opts:       opts,
+		stats:      newRouteStats(opts, dests, sched),
+		breakdowns: newRouteBreakdowns(opts, dests, sched),
 	}
 }
 
@@ -28,8 +30,16 @@ func (rs *routes) Flush() {
 	rs.breakdowns.Flush()
 }
 
+// Notify adds the route metric to route stats and breakdowns. The route is
+// matched against RouteTemplates and, when EnableRouteNormalization is set,
+// normalized, e.g. /users/123 becomes /users/:id, before filters are
+// applied.
 func (rs *routes) Notify(c context.Context, metric *RouteMetric) error {
 	metric.finish()
+	if name := metric.refinishedSpan(); name != "" {
+		rs.opts.Load().log().Warn("span is already finished", "span", name)
+	}
+	metric.Route = rs.opts.routeNormalizer().normalize(metric.Route)
 
 	for _, fn := range rs.filters {
 		metric = fn(metric)
@@ -1,13 +1,8 @@
 package gobrake
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"net/http"
-	"sync"
 	"time"
 )
 
@@ -24,49 +19,41 @@ type routeBreakdown struct {
 }
 
 type routeBreakdowns struct {
-	opt        *NotifierOptions
-	flushTimer *time.Timer
-	addWG      *sync.WaitGroup
-
-	mu sync.Mutex
-	m  map[routeBreakdownKey]*routeBreakdown
+	opts  *sharedOptions
+	dests destinations
+	agg   *aggregator
 }
 
-func newRouteBreakdowns(opt *NotifierOptions) *routeBreakdowns {
-	return &routeBreakdowns{
-		opt: opt,
+type routeBreakdownsAggregate map[routeBreakdownKey]*routeBreakdown
+
+func (m routeBreakdownsAggregate) merge(other aggregate) error {
+	for k, o := range other.(routeBreakdownsAggregate) {
+		b, ok := m[k]
+		if !ok {
+			m[k] = o
+			continue
+		}
+		if err := b.merge(&o.tdigestStatGroups); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (s *routeBreakdowns) init() {
-	if s.flushTimer == nil {
-		s.flushTimer = time.AfterFunc(flushPeriod, s.Flush)
-		s.addWG = new(sync.WaitGroup)
-		s.m = make(map[routeBreakdownKey]*routeBreakdown)
+func newRouteBreakdowns(opts *sharedOptions, dests destinations, sched *apmScheduler) *routeBreakdowns {
+	s := &routeBreakdowns{
+		opts:  opts,
+		dests: dests,
 	}
+	s.agg = sched.newAggregator("routeBreakdowns", func() aggregate {
+		return make(routeBreakdownsAggregate)
+	}, s.send)
+	return s
 }
 
 // Flush sends to Airbrake route stats.
 func (s *routeBreakdowns) Flush() {
-	s.mu.Lock()
-
-	s.flushTimer = nil
-	addWG := s.addWG
-	s.addWG = nil
-	m := s.m
-	s.m = nil
-
-	s.mu.Unlock()
-
-	if m == nil {
-		return
-	}
-
-	addWG.Wait()
-	err := s.send(m)
-	if err != nil {
-		logger.Printf("routeBreakdowns.send failed: %s", err)
-	}
+	s.agg.flush()
 }
 
 type breakdownsOut struct {
@@ -74,9 +61,9 @@ type breakdownsOut struct {
 	Routes []*routeBreakdown `json:"routes"`
 }
 
-func (s *routeBreakdowns) send(m map[routeBreakdownKey]*routeBreakdown) error {
+func (s *routeBreakdowns) send(agg aggregate) error {
 	var routes []*routeBreakdown
-	for _, v := range m {
+	for _, v := range agg.(routeBreakdownsAggregate) {
 		err := v.Pack()
 		if err != nil {
 			return err
@@ -84,68 +71,16 @@ func (s *routeBreakdowns) send(m map[routeBreakdownKey]*routeBreakdown) error {
 		routes = append(routes, v)
 	}
 
-	buf := buffers.Get().(*bytes.Buffer)
-	defer buffers.Put(buf)
-	buf.Reset()
-
 	out := breakdownsOut{
-		Env:    s.opt.Environment,
+		Env:    s.opts.Load().Environment,
 		Routes: routes,
 	}
-	err := json.NewEncoder(buf).Encode(out)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest(
-		http.MethodPut,
-		fmt.Sprintf("%s/api/v5/projects/%d/routes-breakdowns",
-			s.opt.APMHost, s.opt.ProjectId),
-		buf,
-	)
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+s.opt.ProjectKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-	resp, err := s.opt.HTTPClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	buf.Reset()
-	_, err = buf.ReadFrom(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return nil
-	}
-
-	switch resp.StatusCode {
-	case http.StatusUnauthorized:
-		return errUnauthorized
-	case http.StatusBadRequest, http.StatusTooManyRequests:
-		var sendResp sendResponse
-		err = json.NewDecoder(buf).Decode(&sendResp)
-		if err != nil {
-			return err
-		}
-		return errors.New(sendResp.Message)
-	case 404, 408, 409, 410, 500, 502, 504:
-		setRouteBreakdownBacklog(out)
-	}
-
-	err = fmt.Errorf("got unexpected response status=%q", resp.Status)
-	return err
+	return s.dests.sendAPM("routes-breakdowns", out)
 }
 
 func (s *routeBreakdowns) Notify(c context.Context, metric *RouteMetric) error {
-	if s.opt.DisableAPM {
+	opt := s.opts.Load()
+	if opt.DisableAPM {
 		return fmt.Errorf(
 			"APM is disabled, route breakdown is not sent: %s %s (status %d)",
 			metric.Method, metric.Route, metric.StatusCode,
@@ -166,25 +101,28 @@ func (s *routeBreakdowns) Notify(c context.Context, metric *RouteMetric) error {
 		Method:   metric.Method,
 		Route:    metric.Route,
 		RespType: metric.respType(),
-		Time:     metric.startTime.UTC().Truncate(time.Minute),
+		Time:     bucketTime(metric.startTime, opt.apmBucketResolution()),
 	}
 
-	s.mu.Lock()
-	s.init()
-	b, ok := s.m[key]
-	if !ok {
-		b = &routeBreakdown{
-			routeBreakdownKey: key,
-		}
-		s.m[key] = b
-	}
-	addWG := s.addWG
-	addWG.Add(1)
-	s.mu.Unlock()
+	// Groups are read in place so the map is reused with the metric.
+	metric.groupsMu.Lock()
+	defer metric.groupsMu.Unlock()
 
-	groups := metric.flushGroups()
-	b.Add(total, groups)
-	addWG.Done()
-
-	return nil
+	return s.agg.add(func(agg aggregate) error {
+		m := agg.(routeBreakdownsAggregate)
+		k := key
+		b, ok := m[k]
+		if !ok && !s.agg.admit(routeName{k.Method, k.Route}, opt.APMMaxKeys) {
+			k.Route = otherKey
+			b, ok = m[k]
+		}
+		if !ok {
+			b = &routeBreakdown{
+				routeBreakdownKey: k,
+			}
+			m[k] = b
+		}
+		b.add(total, metric.groups)
+		return nil
+	})
 }
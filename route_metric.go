@@ -3,6 +3,9 @@ package gobrake
 import (
 	"context"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type RouteMetric struct {
@@ -12,24 +15,62 @@ type RouteMetric struct {
 	StatusCode  int
 	ContentType string
 
-	root Span
+	root     Span
+	rootSpan span
 }
 
 var _ Metric = (*RouteMetric)(nil)
 
+var routeMetricPool = sync.Pool{
+	New: func() interface{} {
+		return new(RouteMetric)
+	},
+}
+
+// NewRouteMetric starts measuring a request.
 func NewRouteMetric(c context.Context, method, route string) (context.Context, *RouteMetric) {
-	t := &RouteMetric{
-		Method: method,
-		Route:  route,
-	}
+	t := routeMetricPool.Get().(*RouteMetric)
+	t.Method = method
+	t.Route = route
 	t.metric.init()
-	if c != nil {
-		c = withMetric(c, t)
-	}
-	c, t.root = t.Start(c, "http.handler")
+	c = t.metric.startRoot(c, t, &t.rootSpan, "http.handler")
+	t.root = &t.rootSpan
 	return c, t
 }
 
+// Release returns the metric to the pool after it was passed to
+// Routes.Notify, so the next request doesn't allocate a new one. Calling
+// Release is optional. The metric must not be used after Release, while its
+// context and spans become no-ops.
+func (t *RouteMetric) Release() {
+	if t == nil {
+		return
+	}
+
+	t.groupsMu.Lock()
+	atomic.AddUint32(&t.gen, 1)
+	for name := range t.groups {
+		delete(t.groups, name)
+	}
+	t.refinished = ""
+	t.groupsMu.Unlock()
+
+	t.Method = ""
+	t.Route = ""
+	t.StatusCode = 0
+	t.ContentType = ""
+	t.root = nil
+	t.rootSpan = span{}
+	t.startTime = time.Time{}
+	t.endTime = time.Time{}
+
+	routeMetricPool.Put(t)
+}
+
+// ContextRouteMetric returns the metric started by NewRouteMetric for c, or
+// nil. The metric stays valid until it is released: after RouteMetric.Release
+// ContextRouteMetric returns nil, and spans and HTTP requests of c are not
+// recorded. The bundled middlewares never release their metrics.
 func ContextRouteMetric(c context.Context) *RouteMetric {
 	if c == nil {
 		return nil
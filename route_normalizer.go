@@ -0,0 +1,205 @@
+package gobrake
+
+import (
+	"strings"
+)
+
+// Placeholders that replace route segments.
+const (
+	routeIDPlaceholder   = ":id"
+	routeUUIDPlaceholder = ":uuid"
+	routeHashPlaceholder = ":hash"
+	routeDatePlaceholder = ":date"
+)
+
+// Minimum length of hex segments that are replaced with routeHashPlaceholder.
+const minRouteHashLen = 16
+
+// routeNormalizer reduces the number of distinct routes, e.g. /users/123 and
+// /users/456 are both reported as /users/:id.
+type routeNormalizer struct {
+	// Options the normalizer was created from.
+	opt *NotifierOptions
+
+	templates []routeTemplate
+	auto      bool
+}
+
+func newRouteNormalizer(opt *NotifierOptions) *routeNormalizer {
+	n := &routeNormalizer{
+		opt:  opt,
+		auto: opt.EnableRouteNormalization,
+	}
+	for _, tmpl := range opt.RouteTemplates {
+		n.templates = append(n.templates, newRouteTemplate(tmpl))
+	}
+	return n
+}
+
+// normalize returns the first template that matches the route. Otherwise
+// it replaces numeric ids, UUIDs, hashes and dates in the route with
+// placeholders.
+func (n *routeNormalizer) normalize(route string) string {
+	for i := range n.templates {
+		if n.templates[i].match(route) {
+			return n.templates[i].template
+		}
+	}
+	if !n.auto {
+		return route
+	}
+	return normalizeRoute(route)
+}
+
+// normalizeRoute replaces segments of the route with placeholders. The route
+// is returned as is when no segment is replaced.
+func normalizeRoute(route string) string {
+	var sb strings.Builder
+	changed := false
+
+	start := 0
+	for {
+		end := strings.IndexByte(route[start:], '/')
+		if end == -1 {
+			end = len(route)
+		} else {
+			end += start
+		}
+
+		seg := route[start:end]
+		if p := routePlaceholder(seg); p != "" {
+			if !changed {
+				sb.Grow(len(route))
+				sb.WriteString(route[:start])
+				changed = true
+			}
+			sb.WriteString(p)
+		} else if changed {
+			sb.WriteString(seg)
+		}
+
+		if end == len(route) {
+			break
+		}
+		if changed {
+			sb.WriteByte('/')
+		}
+		start = end + 1
+	}
+
+	if !changed {
+		return route
+	}
+	return sb.String()
+}
+
+// routePlaceholder returns the placeholder that replaces the route segment
+// or "" if the segment is kept.
+func routePlaceholder(seg string) string {
+	switch {
+	case seg == "":
+		return ""
+	case isDigits(seg):
+		return routeIDPlaceholder
+	case isUUID(seg):
+		return routeUUIDPlaceholder
+	case isDate(seg):
+		return routeDatePlaceholder
+	case len(seg) >= minRouteHashLen && isHex(seg):
+		return routeHashPlaceholder
+	}
+	return ""
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID reports whether s looks like 123e4567-e89b-12d3-a456-426614174000.
+func isUUID(s string) bool {
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return false
+	}
+	return isHex(s[:8]) && isHex(s[9:13]) && isHex(s[14:18]) && isHex(s[19:23]) && isHex(s[24:])
+}
+
+// isDate reports whether s looks like 2006-01-02.
+func isDate(s string) bool {
+	return len(s) == 10 && s[4] == '-' && s[7] == '-' &&
+		isDigits(s[:4]) && isDigits(s[5:7]) && isDigits(s[8:])
+}
+
+//------------------------------------------------------------------------------
+
+// routeTemplate is a route such as /users/:id. Segments starting with ":" or
+// enclosed in braces, e.g. {id}, match any non-empty segment. A last segment
+// "*" or {name...} matches the rest of the route.
+type routeTemplate struct {
+	template string
+	segs     []string
+}
+
+func newRouteTemplate(template string) routeTemplate {
+	return routeTemplate{
+		template: template,
+		segs:     strings.Split(template, "/"),
+	}
+}
+
+func (t *routeTemplate) match(route string) bool {
+	i := 0
+	start := 0
+	for {
+		end := strings.IndexByte(route[start:], '/')
+		if end == -1 {
+			end = len(route)
+		} else {
+			end += start
+		}
+
+		if i == len(t.segs) {
+			return false
+		}
+		seg := route[start:end]
+		tseg := t.segs[i]
+		if i == len(t.segs)-1 && isRouteWildcard(tseg) {
+			return true
+		}
+		if tseg != seg && !(isRouteParam(tseg) && seg != "") {
+			return false
+		}
+		i++
+
+		if end == len(route) {
+			break
+		}
+		start = end + 1
+	}
+	return i == len(t.segs)
+}
+
+func isRouteParam(seg string) bool {
+	if len(seg) > 1 && seg[0] == ':' {
+		return true
+	}
+	return len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}'
+}
+
+func isRouteWildcard(seg string) bool {
+	return seg == "*" || strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}")
+}
@@ -0,0 +1,89 @@
+package gobrake
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("routeNormalizer", func() {
+	It("replaces ids, UUIDs, hashes and dates with placeholders", func() {
+		n := newRouteNormalizer(&NotifierOptions{EnableRouteNormalization: true})
+
+		tests := []struct {
+			route string
+			want  string
+		}{
+			{"/", "/"},
+			{"", ""},
+			{"/users", "/users"},
+			{"/users/123", "/users/:id"},
+			{"/users/123/", "/users/:id/"},
+			{"/users/123/posts/456", "/users/:id/posts/:id"},
+			{"/users/:id", "/users/:id"},
+			{"/v1/users", "/v1/users"},
+			{"/orders/123e4567-e89b-12d3-a456-426614174000", "/orders/:uuid"},
+			{"/blobs/d41d8cd98f00b204e9800998ecf8427e", "/blobs/:hash"},
+			{"/commits/DA39A3EE5E6B4B0D3255BFEF95601890AFD80709/files", "/commits/:hash/files"},
+			{"/reports/2024-01-31", "/reports/:date"},
+			{"/reports/2024-01", "/reports/2024-01"},
+			{"/cafe", "/cafe"},
+			{"123", ":id"},
+		}
+		for _, test := range tests {
+			Expect(n.normalize(test.route)).To(Equal(test.want), test.route)
+		}
+	})
+
+	It("reports routes that match templates as the template", func() {
+		n := newRouteNormalizer(&NotifierOptions{
+			RouteTemplates: []string{
+				"/users/:name",
+				"/teams/{team}/members/{member}",
+				"/static/*",
+				"/files/{path...}",
+			},
+			EnableRouteNormalization: true,
+		})
+
+		tests := []struct {
+			route string
+			want  string
+		}{
+			{"/users/john", "/users/:name"},
+			{"/users/123", "/users/:name"},
+			{"/users/", "/users/"},
+			{"/users/john/posts", "/users/john/posts"},
+			{"/teams/core/members/jane", "/teams/{team}/members/{member}"},
+			{"/static/css/app.css", "/static/*"},
+			{"/static", "/static"},
+			{"/files/a/b/c", "/files/{path...}"},
+			{"/posts/123", "/posts/:id"},
+		}
+		for _, test := range tests {
+			Expect(n.normalize(test.route)).To(Equal(test.want), test.route)
+		}
+	})
+
+	It("applies only templates by default", func() {
+		n := newRouteNormalizer(&NotifierOptions{
+			RouteTemplates: []string{"/users/:id"},
+		})
+
+		Expect(n.normalize("/users/123")).To(Equal("/users/:id"))
+		Expect(n.normalize("/posts/123")).To(Equal("/posts/123"))
+	})
+
+	It("does not allocate when the route is unchanged", func() {
+		n := newRouteNormalizer(&NotifierOptions{
+			RouteTemplates:           []string{"/users/:id"},
+			EnableRouteNormalization: true,
+		})
+
+		allocs := testing.AllocsPerRun(100, func() {
+			n.normalize("/api/v1/projects/:id/notices")
+		})
+		Expect(allocs).To(BeZero())
+	})
+})
@@ -1,13 +1,8 @@
 package gobrake
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"net/http"
-	"sync"
 	"time"
 )
 
@@ -18,6 +13,12 @@ type routeKey struct {
 	Time       time.Time `json:"time"`
 }
 
+// routeName identifies a route when APMMaxKeys is counted.
+type routeName struct {
+	Method string
+	Route  string
+}
+
 type routeKeyStat struct {
 	routeKey
 	*tdigestStat
@@ -26,51 +27,43 @@ type routeKeyStat struct {
 // routeStats aggregates information about requests and periodically sends
 // collected data to Airbrake.
 type routeStats struct {
-	opt        *NotifierOptions
-	flushTimer *time.Timer
-	addWG      *sync.WaitGroup
-
-	mu sync.Mutex
-	m  map[routeKey]*tdigestStat
+	opts  *sharedOptions
+	dests destinations
+	agg   *aggregator
 }
 
 type routeFilter func(*RouteMetric) *RouteMetric
 
-func newRouteStats(opt *NotifierOptions) *routeStats {
-	return &routeStats{
-		opt: opt,
+type routeStatsAggregate map[routeKey]*tdigestStat
+
+func (m routeStatsAggregate) merge(other aggregate) error {
+	for k, o := range other.(routeStatsAggregate) {
+		stat, ok := m[k]
+		if !ok {
+			m[k] = o
+			continue
+		}
+		if err := stat.merge(o); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (s *routeStats) init() {
-	if s.flushTimer == nil {
-		s.flushTimer = time.AfterFunc(flushPeriod, s.Flush)
-		s.addWG = new(sync.WaitGroup)
-		s.m = make(map[routeKey]*tdigestStat)
+func newRouteStats(opts *sharedOptions, dests destinations, sched *apmScheduler) *routeStats {
+	s := &routeStats{
+		opts:  opts,
+		dests: dests,
 	}
+	s.agg = sched.newAggregator("routeStats", func() aggregate {
+		return make(routeStatsAggregate)
+	}, s.send)
+	return s
 }
 
 // Flush sends to Airbrake route stats.
 func (s *routeStats) Flush() {
-	s.mu.Lock()
-
-	s.flushTimer = nil
-	addWG := s.addWG
-	s.addWG = nil
-	m := s.m
-	s.m = nil
-
-	s.mu.Unlock()
-
-	if m == nil {
-		return
-	}
-
-	addWG.Wait()
-	err := s.send(m)
-	if err != nil {
-		logger.Printf("routeStats.send failed: %s", err)
-	}
+	s.agg.flush()
 }
 
 type routesOut struct {
@@ -78,9 +71,9 @@ type routesOut struct {
 	Routes []routeKeyStat `json:"routes"`
 }
 
-func (s *routeStats) send(m map[routeKey]*tdigestStat) error {
+func (s *routeStats) send(agg aggregate) error {
 	var routes []routeKeyStat
-	for k, v := range m {
+	for k, v := range agg.(routeStatsAggregate) {
 		err := v.Pack()
 		if err != nil {
 			return err
@@ -92,69 +85,17 @@ func (s *routeStats) send(m map[routeKey]*tdigestStat) error {
 		})
 	}
 
-	buf := buffers.Get().(*bytes.Buffer)
-	defer buffers.Put(buf)
-	buf.Reset()
-
 	out := routesOut{
-		Env:    s.opt.Environment,
+		Env:    s.opts.Load().Environment,
 		Routes: routes,
 	}
-	err := json.NewEncoder(buf).Encode(out)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest(
-		http.MethodPut,
-		fmt.Sprintf("%s/api/v5/projects/%d/routes-stats",
-			s.opt.APMHost, s.opt.ProjectId),
-		buf,
-	)
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+s.opt.ProjectKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-	resp, err := s.opt.HTTPClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	buf.Reset()
-	_, err = buf.ReadFrom(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return nil
-	}
-
-	switch resp.StatusCode {
-	case http.StatusUnauthorized:
-		return errUnauthorized
-	case http.StatusBadRequest, http.StatusTooManyRequests:
-		var sendResp sendResponse
-		err = json.NewDecoder(buf).Decode(&sendResp)
-		if err != nil {
-			return err
-		}
-		return errors.New(sendResp.Message)
-	case 404, 408, 409, 410, 500, 502, 504:
-		setRouteStatBacklog(out)
-	}
-
-	err = fmt.Errorf("got unexpected response status=%q", resp.Status)
-	return err
+	return s.dests.sendAPM("routes-stats", out)
 }
 
 // Notify adds new route stats.
 func (s *routeStats) Notify(c context.Context, req *RouteMetric) error {
-	if s.opt.DisableAPM {
+	opt := s.opts.Load()
+	if opt.DisableAPM {
 		return fmt.Errorf(
 			"APM is disabled, route is not sent: %s %s (status %d)",
 			req.Method, req.Route, req.StatusCode,
@@ -165,23 +106,22 @@ func (s *routeStats) Notify(c context.Context, req *RouteMetric) error {
 		Method:     req.Method,
 		Route:      req.Route,
 		StatusCode: req.StatusCode,
-		Time:       req.startTime.UTC().Truncate(time.Minute),
-	}
-
-	s.mu.Lock()
-	s.init()
-	stat, ok := s.m[key]
-	if !ok {
-		stat = newTDigestStat()
-		s.m[key] = stat
+		Time:       bucketTime(req.startTime, opt.apmBucketResolution()),
 	}
-	addWG := s.addWG
-	addWG.Add(1)
-	s.mu.Unlock()
-
 	dur := req.endTime.Sub(req.startTime)
-	err := stat.Add(dur)
-	addWG.Done()
 
-	return err
+	return s.agg.add(func(agg aggregate) error {
+		m := agg.(routeStatsAggregate)
+		k := key
+		stat, ok := m[k]
+		if !ok && !s.agg.admit(routeName{k.Method, k.Route}, opt.APMMaxKeys) {
+			k.Route = otherKey
+			stat, ok = m[k]
+		}
+		if !ok {
+			stat = newTDigestStat()
+			m[k] = stat
+		}
+		return stat.add(dur)
+	})
 }
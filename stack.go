@@ -7,16 +7,34 @@ import (
 	"github.com/pkg/errors"
 )
 
+// How many frames are collected for a backtrace. Backtraces are trimmed to
+// NotifierOptions.MaxBacktraceDepth later.
+const maxCollectedFrames = 128
+
+const defaultMaxBacktraceDepth = 32
+
+// Frame origins.
+const (
+	originApp        = "app"
+	originStdlib     = "stdlib"
+	originDependency = "dependency"
+	originGobrake    = "gobrake"
+)
+
+const gobrakeModule = "github.com/airbrake/gobrake/v5"
+
 // getBacktrace returns the stacktrace associated with e. If e is an
-// error from the errors package its stacktrace is extracted, otherwise
-// the current stacktrace is collected end returned.
+// error that carries a stacktrace, directly or in its Unwrap chain, that
+// stacktrace is extracted, otherwise the current stacktrace is collected
+// end returned.
 func getBacktrace(e interface{}, skip int) (string, []StackFrame) {
-	if err, ok := e.(stackTracer); ok {
-		return backtraceFromErrorWithStackTrace(err)
+	if err, ok := e.(error); ok {
+		if pcs, ok := extractStack(err); ok {
+			return backtraceFromPCs(pcs)
+		}
 	}
 
-	const depth = 32
-	var pcs [depth]uintptr
+	var pcs [maxCollectedFrames]uintptr
 	n := runtime.Callers(skip+1, pcs[:])
 	ff := runtime.CallersFrames(pcs[:n])
 
@@ -42,6 +60,7 @@ func getBacktrace(e interface{}, skip int) (string, []StackFrame) {
 			File: f.File,
 			Line: f.Line,
 			Func: fn,
+			pkg:  pkg,
 		})
 	}
 
@@ -73,12 +92,11 @@ type stackTracer interface {
 
 // backtraceFromErrorWithStackTrace extracts the stacktrace from e.
 func backtraceFromErrorWithStackTrace(e stackTracer) (string, []StackFrame) {
-	stackTrace := e.StackTrace()
-	var pcs []uintptr
-	for _, f := range stackTrace {
-		pcs = append(pcs, uintptr(f))
-	}
+	return backtraceFromPCs(stackTracerPCs(e))
+}
 
+// backtraceFromPCs converts program counters to the backtrace.
+func backtraceFromPCs(pcs []uintptr) (string, []StackFrame) {
 	ff := runtime.CallersFrames(pcs)
 	var firstPkg string
 	frames := make([]StackFrame, 0)
@@ -97,8 +115,98 @@ func backtraceFromErrorWithStackTrace(e stackTracer) (string, []StackFrame) {
 			File: f.File,
 			Line: f.Line,
 			Func: fn,
+			pkg:  pkg,
 		})
 	}
 
 	return firstPkg, frames
 }
+
+// frameOrigin classifies the package pkg of the frame.
+func frameOrigin(pkg string, frame *StackFrame, paths *modulePaths) string {
+	pkg = strings.TrimSuffix(pkg, "_test")
+
+	if pkg == "main" || hasPathPrefix(pkg, paths.modulePath) {
+		return originApp
+	}
+	if hasPathPrefix(pkg, gobrakeModule) {
+		return originGobrake
+	}
+	if frame.Version != "" {
+		return originDependency
+	}
+	if _, ok := paths.modCacheRel(frame.File); ok {
+		return originDependency
+	}
+
+	first := pkg
+	if ind := strings.IndexByte(pkg, '/'); ind != -1 {
+		first = pkg[:ind]
+	}
+	if pkg != "" && !strings.Contains(first, ".") {
+		return originStdlib
+	}
+	// Packages outside of the main module, or any packages when the main
+	// module is unknown, are dependencies.
+	return originDependency
+}
+
+func hasPathPrefix(pkg, prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	return pkg == prefix || strings.HasPrefix(pkg, prefix+"/")
+}
+
+// newBacktraceFilter strips frames of ignored packages from the top of
+// backtraces, trims backtraces to the max depth and classifies frames.
+func newBacktraceFilter(opts *sharedOptions) func(*Notice) *Notice {
+	return func(notice *Notice) *Notice {
+		opt := opts.Load()
+		ignored := opt.IgnoredPackages
+		maxDepth := opt.MaxBacktraceDepth
+		paths := getModulePaths()
+
+		for i := range notice.Errors {
+			e := &notice.Errors[i]
+
+			n := 0
+			for n < len(e.Backtrace) && isIgnoredPackage(e.Backtrace[n].pkg, ignored) {
+				n++
+			}
+			if n > 0 && n < len(e.Backtrace) {
+				e.Backtrace = e.Backtrace[n:]
+				if i == 0 && notice.Context != nil {
+					notice.Context["component"] = e.Backtrace[0].pkg
+				}
+			}
+
+			if maxDepth > 0 && len(e.Backtrace) > maxDepth {
+				e.Backtrace = e.Backtrace[:maxDepth]
+			}
+
+			for j := range e.Backtrace {
+				frame := &e.Backtrace[j]
+				if frame.pkg == "" {
+					continue
+				}
+				frame.Origin = frameOrigin(frame.pkg, frame, paths)
+				frame.InApp = frame.Origin == originApp
+			}
+		}
+
+		return notice
+	}
+}
+
+func isIgnoredPackage(pkg string, ignored []string) bool {
+	if pkg == "" {
+		return false
+	}
+	for _, prefix := range ignored {
+		if hasPathPrefix(pkg, prefix) {
+			return true
+		}
+	}
+	return false
+}
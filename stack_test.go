@@ -1,6 +1,10 @@
 package gobrake
 
 import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+
 	testpkg1 "github.com/airbrake/gobrake/v5/internal/testpkg1"
 	testpkg2 "github.com/airbrake/gobrake/v5/internal/testpkg2"
 
@@ -38,3 +42,185 @@ var _ = Describe("backtraceFromErrorWithStackTrace", func() {
 		}
 	})
 })
+
+type callersError struct {
+	pcs []uintptr
+}
+
+func newCallersError() *callersError {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+	return &callersError{pcs: pcs[:n]}
+}
+
+func (e *callersError) Error() string      { return "callers error" }
+func (e *callersError) Callers() []uintptr { return e.pcs }
+
+type goErrorsFrame struct {
+	File           string
+	LineNumber     int
+	ProgramCounter uintptr
+}
+
+type goErrorsError struct {
+	frames []goErrorsFrame
+}
+
+func newGoErrorsError() *goErrorsError {
+	e := new(goErrorsError)
+	for _, pc := range newCallersError().pcs {
+		e.frames = append(e.frames, goErrorsFrame{ProgramCounter: pc})
+	}
+	return e
+}
+
+func (e *goErrorsError) Error() string                { return "go-errors error" }
+func (e *goErrorsError) StackFrames() []goErrorsFrame { return e.frames }
+
+type emperrorFrame uintptr
+
+type emperrorError struct {
+	frames []emperrorFrame
+}
+
+func newEmperrorError() *emperrorError {
+	e := new(emperrorError)
+	for _, pc := range newCallersError().pcs {
+		e.frames = append(e.frames, emperrorFrame(pc))
+	}
+	return e
+}
+
+func (e *emperrorError) Error() string               { return "emperror error" }
+func (e *emperrorError) StackTrace() []emperrorFrame { return e.frames }
+
+type customError struct{}
+
+func (customError) Error() string { return "custom error" }
+
+type panickingError struct{}
+
+func (panickingError) Error() string { return "panicking error" }
+func (panickingError) StackFrames() []struct{ ProgramCounter uintptr } {
+	panic("no stack frames")
+}
+
+var _ = Describe("getBacktrace", func() {
+	It("extracts backtraces from errors of other libraries", func() {
+		for _, err := range []error{
+			newCallersError(),
+			newGoErrorsError(),
+			newEmperrorError(),
+		} {
+			_, backtrace := getBacktrace(err, 0)
+			Expect(backtrace).NotTo(BeEmpty(), err.Error())
+			Expect(backtrace[0].Func).To(Equal("newCallersError"), err.Error())
+		}
+	})
+
+	It("uses the first error with a stack trace in the Unwrap chain", func() {
+		err := fmt.Errorf("wrapped: %w", testpkg1.Foo())
+
+		packageName, backtrace := getBacktrace(err, 0)
+		Expect(packageName).To(Equal("github.com/airbrake/gobrake/v5/internal/testpkg1"))
+		Expect(backtrace[0].Func).To(Equal("Bar"))
+	})
+
+	It("collects current stack trace when errors have none", func() {
+		_, backtrace := getBacktrace(fmt.Errorf("wrapped: %w", customError{}), 1)
+		Expect(backtrace[0].Func).To(ContainSubstring("init."))
+	})
+
+	It("supports registered extractors", func() {
+		pcs := newCallersError().pcs
+		RegisterBacktraceExtractor(func(err error) ([]uintptr, bool) {
+			if _, ok := err.(customError); ok {
+				return pcs, true
+			}
+			return nil, false
+		})
+
+		_, backtrace := getBacktrace(customError{}, 0)
+		Expect(backtrace[0].Func).To(Equal("newCallersError"))
+	})
+
+	It("tries registered extractors before the built-in ones", func() {
+		backtraceExtractorsMu.Lock()
+		saved := userBacktraceExtractors
+		backtraceExtractorsMu.Unlock()
+		DeferCleanup(func() {
+			backtraceExtractorsMu.Lock()
+			userBacktraceExtractors = saved
+			backtraceExtractorsMu.Unlock()
+		})
+
+		pcs := newCallersError().pcs
+		RegisterBacktraceExtractor(func(err error) ([]uintptr, bool) {
+			if _, ok := err.(stackTracer); ok {
+				return pcs, true
+			}
+			return nil, false
+		})
+
+		_, backtrace := getBacktrace(testpkg1.Foo(), 0)
+		Expect(backtrace[0].Func).To(Equal("newCallersError"))
+	})
+
+	It("ignores nil and panicking StackTrace methods", func() {
+		var nilErr *emperrorError
+		_, ok := reflectStackTraceExtractor(nilErr)
+		Expect(ok).To(BeFalse())
+
+		_, ok = stackFramesExtractor(panickingError{})
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("frameOrigin", func() {
+	paths := &modulePaths{
+		modCache:   "/home/runner/go/pkg/mod",
+		modulePath: "github.com/foo/app",
+	}
+
+	It("classifies frames by package", func() {
+		tests := []struct {
+			pkg    string
+			file   string
+			origin string
+		}{
+			{"main", "/src/main.go", "app"},
+			{"github.com/foo/app/internal", "/src/internal/x.go", "app"},
+			{"github.com/foo/app_test", "/src/x_test.go", "app"},
+			{"net/http", "/usr/local/go/src/net/http/server.go", "stdlib"},
+			{"runtime", "/usr/local/go/src/runtime/panic.go", "stdlib"},
+			{"github.com/airbrake/gobrake/v5/zap", "/x/zap.go", "gobrake"},
+			{"go.uber.org/zap", "/home/runner/go/pkg/mod/go.uber.org/zap@v1.24.0/logger.go", "dependency"},
+			{"github.com/other/lib", "/vendor/lib.go", "dependency"},
+		}
+
+		for _, test := range tests {
+			frame := &StackFrame{File: test.file}
+			Expect(frameOrigin(test.pkg, frame, paths)).To(Equal(test.origin), test.pkg)
+		}
+	})
+
+	It("classifies only main packages as app when the main module is unknown", func() {
+		paths := &modulePaths{}
+
+		Expect(frameOrigin("main", &StackFrame{}, paths)).To(Equal("app"))
+		Expect(frameOrigin("github.com/foo/app", &StackFrame{}, paths)).To(Equal("dependency"))
+		Expect(frameOrigin("net/http", &StackFrame{}, paths)).To(Equal("stdlib"))
+	})
+})
+
+var _ = Describe("StackFrame JSON", func() {
+	It("omits inApp of frames outside of the application", func() {
+		b, err := json.Marshal(StackFrame{File: "/x.go", Origin: originDependency})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).NotTo(ContainSubstring("inApp"))
+
+		b, err = json.Marshal(StackFrame{File: "/x.go", Origin: originApp, InApp: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring(`"inApp":true`))
+	})
+})
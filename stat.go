@@ -1,14 +1,14 @@
 package gobrake
 
 import (
-	"sync"
 	"time"
 
 	tdigest "github.com/caio/go-tdigest/v4"
 )
 
+// tdigestStat is not safe for concurrent use. Stats are guarded by the lock
+// of the aggregator shard.
 type tdigestStat struct {
-	mu      sync.Mutex
 	Count   int     `json:"count"`
 	Sum     float64 `json:"sum"`
 	Sumsq   float64 `json:"sumsq"`
@@ -20,13 +20,6 @@ func newTDigestStat() *tdigestStat {
 	return new(tdigestStat)
 }
 
-func (s *tdigestStat) Add(dur time.Duration) error {
-	s.mu.Lock()
-	err := s.add(dur)
-	s.mu.Unlock()
-	return err
-}
-
 func (s *tdigestStat) add(dur time.Duration) error {
 	if s.td == nil {
 		seed := time.Now().UnixNano()
@@ -45,6 +38,22 @@ func (s *tdigestStat) add(dur time.Duration) error {
 	return s.td.Add(ms)
 }
 
+// merge adds other to the stat.
+func (s *tdigestStat) merge(other *tdigestStat) error {
+	s.Count += other.Count
+	s.Sum += other.Sum
+	s.Sumsq += other.Sumsq
+
+	if other.td == nil {
+		return nil
+	}
+	if s.td == nil {
+		s.td = other.td
+		return nil
+	}
+	return s.td.Merge(other.td)
+}
+
 func (s *tdigestStat) Pack() error {
 	err := s.td.Compress()
 	if err != nil {
@@ -69,12 +78,6 @@ type tdigestStatGroups struct {
 	Groups      map[string]*tdigestStat `json:"groups"`
 }
 
-func (b *tdigestStatGroups) Add(total time.Duration, groups map[string]time.Duration) {
-	b.mu.Lock()
-	b.add(total, groups)
-	b.mu.Unlock()
-}
-
 func (b *tdigestStatGroups) add(total time.Duration, groups map[string]time.Duration) {
 	if b.Groups == nil {
 		b.Groups = make(map[string]*tdigestStat)
@@ -95,6 +98,30 @@ func (b *tdigestStatGroups) addGroup(name string, dur time.Duration) {
 	_ = s.add(dur)
 }
 
+// merge adds other to the stat and its groups.
+func (b *tdigestStatGroups) merge(other *tdigestStatGroups) error {
+	err := b.tdigestStat.merge(&other.tdigestStat)
+	if err != nil {
+		return err
+	}
+
+	if b.Groups == nil {
+		b.Groups = make(map[string]*tdigestStat)
+	}
+	for name, o := range other.Groups {
+		s, ok := b.Groups[name]
+		if !ok {
+			b.Groups[name] = o
+			continue
+		}
+		err = s.merge(o)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *tdigestStatGroups) Pack() error {
 	err := b.tdigestStat.Pack()
 	if err != nil {
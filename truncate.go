@@ -0,0 +1,215 @@
+package gobrake
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+const truncatedValue = "[Truncated]"
+
+// How many frames keep their code hunks when deep frames are dropped.
+const codeHunkFrames = 3
+
+type truncator struct {
+	maxStringLen int
+	maxDepth     int
+	maxArrayLen  int
+}
+
+// Truncation levels applied one by one until the notice fits.
+var truncateLevels = []truncator{
+	{maxStringLen: 1024, maxDepth: 8, maxArrayLen: 128},
+	{maxStringLen: 256, maxDepth: 4, maxArrayLen: 32},
+	{maxStringLen: 64, maxDepth: 2, maxArrayLen: 8},
+}
+
+// truncateNotice progressively shrinks a copy of the notice until its JSON
+// encoding fits in maxNoticeLen. It drops build dependencies, trims Params,
+// Env, Session, Context and error messages, then drops code hunks, and
+// finally trims the goroutine dump and backtraces. buf holds the encoded
+// notice when truncateNotice returns without an error.
+func truncateNotice(notice *Notice, buf *bytes.Buffer) error {
+	// The notice is shared with filters and the caller.
+	notice = cloneNotice(notice)
+	if notice.Context == nil {
+		notice.Context = make(map[string]interface{})
+	}
+	notice.Context["truncated"] = true
+
+	fits := func() (bool, error) {
+		buf.Reset()
+		if err := json.NewEncoder(buf).Encode(notice); err != nil {
+			return false, err
+		}
+		return buf.Len() <= maxNoticeLen, nil
+	}
+
+	steps := make([]func(), 0, len(truncateLevels)+3)
+	steps = append(steps, func() { dropBuildDependencies(notice) })
+	for i := range truncateLevels {
+		t := &truncateLevels[i]
+		steps = append(steps, func() { t.truncateNotice(notice) })
+	}
+	steps = append(steps,
+		func() { dropCodeHunks(notice, codeHunkFrames) },
+		func() { dropCodeHunks(notice, 0) },
+	)
+
+	for _, step := range steps {
+		step()
+		if ok, err := fits(); ok || err != nil {
+			return err
+		}
+	}
+
+	for trimGoroutines(notice) || trimBacktraces(notice) {
+		if ok, err := fits(); ok || err != nil {
+			return err
+		}
+	}
+
+	return errNoticeTooBig
+}
+
+func (t *truncator) truncateNotice(notice *Notice) {
+	for i := range notice.Errors {
+		e := &notice.Errors[i]
+		e.Message = truncateString(e.Message, 4*t.maxStringLen)
+	}
+	notice.Params = t.truncateMap(notice.Params, 0)
+	notice.Env = t.truncateMap(notice.Env, 0)
+	notice.Session = t.truncateMap(notice.Session, 0)
+	notice.Context = t.truncateContext(notice.Context)
+}
+
+// truncateContext truncates context values except the goroutine dump which
+// is trimmed by trimGoroutines.
+func (t *truncator) truncateContext(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k == "goroutines" {
+			out[k] = v
+			continue
+		}
+		out[k] = t.truncate(v, 0)
+	}
+	return out
+}
+
+func (t *truncator) truncateMap(m map[string]interface{}, depth int) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = t.truncate(v, depth)
+	}
+	return out
+}
+
+func (t *truncator) truncate(v interface{}, depth int) interface{} {
+	switch v := v.(type) {
+	case nil, bool, float64, float32, int, int64, int32, uint, uint64, uint32:
+		return v
+	case string:
+		return truncateString(v, t.maxStringLen)
+	case map[string]interface{}:
+		if depth >= t.maxDepth {
+			return truncatedValue
+		}
+		return t.truncateMap(v, depth+1)
+	case []interface{}:
+		if depth >= t.maxDepth {
+			return truncatedValue
+		}
+		if len(v) > t.maxArrayLen {
+			v = v[:t.maxArrayLen]
+		}
+		out := make([]interface{}, len(v))
+		for i, el := range v {
+			out[i] = t.truncate(el, depth+1)
+		}
+		return out
+	case []string:
+		if len(v) > t.maxArrayLen {
+			v = v[:t.maxArrayLen]
+		}
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = truncateString(s, t.maxStringLen)
+		}
+		return out
+	case []byte:
+		if len(v) > t.maxStringLen {
+			v = v[:t.maxStringLen]
+		}
+		return v
+	case json.RawMessage:
+		var generic interface{}
+		if err := json.Unmarshal(v, &generic); err != nil {
+			return truncateString(string(v), t.maxStringLen)
+		}
+		return t.truncate(generic, depth)
+	default:
+		// Convert structs, typed maps and slices to the generic form used
+		// by Notice.MarshalJSON so they can be truncated like the values
+		// above.
+		return t.truncate(safeValue(v), depth)
+	}
+}
+
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	// Don't cut a multi-byte character in half.
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen--
+	}
+	return s[:maxLen] + truncatedValue
+}
+
+// dropBuildDependencies removes the module dependencies from the build info
+// in the notice context.
+func dropBuildDependencies(notice *Notice) {
+	info, ok := notice.Context["buildInfo"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if _, ok := info["dependencies"]; !ok {
+		return
+	}
+	// The build info map may be shared with other notices.
+	m := make(map[string]interface{}, len(info))
+	for k, v := range info {
+		if k != "dependencies" {
+			m[k] = v
+		}
+	}
+	notice.Context["buildInfo"] = m
+}
+
+// dropCodeHunks removes code hunks from all frames except the first keep ones.
+func dropCodeHunks(notice *Notice, keep int) {
+	for i := range notice.Errors {
+		backtrace := notice.Errors[i].Backtrace
+		for j := keep; j < len(backtrace); j++ {
+			backtrace[j].Code = nil
+		}
+	}
+}
+
+// trimBacktraces halves every backtrace longer than one frame. It reports
+// whether any backtrace was trimmed.
+func trimBacktraces(notice *Notice) bool {
+	var trimmed bool
+	for i := range notice.Errors {
+		e := &notice.Errors[i]
+		if len(e.Backtrace) > 1 {
+			e.Backtrace = e.Backtrace[:len(e.Backtrace)/2]
+			trimmed = true
+		}
+	}
+	return trimmed
+}
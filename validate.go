@@ -0,0 +1,112 @@
+package gobrake
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ValidationError describes an invalid notifier option.
+type ValidationError struct {
+	// Option name, e.g. ProjectKey or Destinations[0].Host.
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationErrors is returned by NotifierOptions.Validate and lists all
+// invalid options.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return "gobrake: invalid options: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the options and returns ValidationErrors when the project
+// id or key is missing, a host is not an http or https URL or a value is
+// out of range. Empty hosts are valid, because defaults are used for them.
+func (opt *NotifierOptions) Validate() error {
+	var errs ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	validateProject(add, "", opt.ProjectId, opt.ProjectKey)
+	validateHost(add, "Host", opt.Host)
+	validateHost(add, "APMHost", opt.APMHost)
+	validateHost(add, "RemoteConfigHost", opt.RemoteConfigHost)
+
+	for i, key := range opt.KeysBlocklist {
+		switch key.(type) {
+		case string, *regexp.Regexp:
+		default:
+			add(fmt.Sprintf("KeysBlocklist[%d]", i), "has unsupported type %T", key)
+		}
+	}
+
+	if opt.SamplingRate < 0 || opt.SamplingRate > 1 {
+		add("SamplingRate", "must be between 0 and 1, got %v", opt.SamplingRate)
+	}
+	if opt.APMFlushPeriod < 0 {
+		add("APMFlushPeriod", "must not be negative, got %s", opt.APMFlushPeriod)
+	}
+	if opt.APMBucketResolution < 0 {
+		add("APMBucketResolution", "must not be negative, got %s", opt.APMBucketResolution)
+	}
+	if opt.APMMaxKeys < 0 {
+		add("APMMaxKeys", "must not be negative, got %d", opt.APMMaxKeys)
+	}
+	for i, tmpl := range opt.RouteTemplates {
+		if !strings.HasPrefix(tmpl, "/") {
+			add(fmt.Sprintf("RouteTemplates[%d]", i), "must start with /, got %q", tmpl)
+		}
+	}
+
+	for i, d := range opt.Destinations {
+		prefix := fmt.Sprintf("Destinations[%d].", i)
+		validateProject(add, prefix, d.ProjectId, d.ProjectKey)
+		validateHost(add, prefix+"Host", d.Host)
+		validateHost(add, prefix+"APMHost", d.APMHost)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateProject(
+	add func(field, format string, args ...interface{}),
+	prefix string, projectId int64, projectKey string,
+) {
+	if projectId <= 0 {
+		add(prefix+"ProjectId", "is required")
+	}
+	if projectKey == "" {
+		add(prefix+"ProjectKey", "is required")
+	}
+}
+
+func validateHost(
+	add func(field, format string, args ...interface{}),
+	field, host string,
+) {
+	if host == "" {
+		return
+	}
+	u, err := url.Parse(host)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add(field, "must be an http or https URL, got %q", host)
+	}
+}
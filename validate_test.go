@@ -0,0 +1,54 @@
+package gobrake_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/airbrake/gobrake/v5"
+)
+
+var _ = Describe("(*NotifierOptions).Validate()", func() {
+	It("accepts valid options", func() {
+		opt := &gobrake.NotifierOptions{
+			ProjectId:  1,
+			ProjectKey: "key",
+			Host:       "https://api.airbrake.io",
+		}
+		Expect(opt.Validate()).To(Succeed())
+	})
+
+	It("returns all invalid options", func() {
+		opt := &gobrake.NotifierOptions{
+			Host:           "api.airbrake.io",
+			SamplingRate:   2,
+			RouteTemplates: []string{"/users/:id", "posts/:id"},
+			KeysBlocklist:  []interface{}{"password", 1},
+			Destinations: []gobrake.Destination{{
+				ProjectId: 2,
+			}},
+		}
+
+		err := opt.Validate()
+		var errs gobrake.ValidationErrors
+		Expect(errors.As(err, &errs)).To(BeTrue())
+
+		var fields []string
+		for _, e := range errs {
+			fields = append(fields, e.Field)
+		}
+		Expect(fields).To(Equal([]string{
+			"ProjectId",
+			"ProjectKey",
+			"Host",
+			"KeysBlocklist[1]",
+			"SamplingRate",
+			"RouteTemplates[1]",
+			"Destinations[0].ProjectKey",
+		}))
+		Expect(err).To(MatchError(ContainSubstring(
+			`Host must be an http or https URL, got "api.airbrake.io"`,
+		)))
+	})
+})
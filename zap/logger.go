@@ -0,0 +1,34 @@
+package zap
+
+import (
+	"github.com/airbrake/gobrake/v5"
+	uberzap "go.uber.org/zap"
+)
+
+type logger struct {
+	l *uberzap.SugaredLogger
+}
+
+// NewLogger returns gobrake.Logger that writes notifier messages to l.
+// Use it as NotifierOptions.Logger.
+func NewLogger(l *uberzap.Logger) gobrake.Logger {
+	return &logger{
+		l: l.WithOptions(uberzap.AddCallerSkip(1)).Sugar(),
+	}
+}
+
+func (l *logger) Debug(msg string, keyvals ...interface{}) {
+	l.l.Debugw(msg, keyvals...)
+}
+
+func (l *logger) Info(msg string, keyvals ...interface{}) {
+	l.l.Infow(msg, keyvals...)
+}
+
+func (l *logger) Warn(msg string, keyvals ...interface{}) {
+	l.l.Warnw(msg, keyvals...)
+}
+
+func (l *logger) Error(msg string, keyvals ...interface{}) {
+	l.l.Errorw(msg, keyvals...)
+}
@@ -0,0 +1,38 @@
+package zap
+
+import (
+	"testing"
+
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewLogger(uberzap.New(core))
+
+	l.Debug("debug message", "key", 1)
+	l.Info("info message", "key", 2)
+	l.Warn("warn message", "key", 3)
+	l.Error("error message", "key", 4)
+
+	entries := logs.AllUntimed()
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, wanted 4", len(entries))
+	}
+	levels := []zapcore.Level{
+		zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel,
+	}
+	for i, e := range entries {
+		if e.Level != levels[i] {
+			t.Fatalf("got level %s, wanted %s", e.Level, levels[i])
+		}
+		if want := levels[i].String() + " message"; e.Message != want {
+			t.Fatalf("got message %q, wanted %q", e.Message, want)
+		}
+		if got := e.ContextMap()["key"]; got != int64(i+1) {
+			t.Fatalf("got key=%v, wanted %d", got, i+1)
+		}
+	}
+}
@@ -12,6 +12,8 @@ type Core struct {
 	Notifier   *gobrake.Notifier
 	coreFields map[string]interface{}
 	depth      int
+
+	breadcrumbLevel zapcore.LevelEnabler
 }
 
 func NewCore(enab zapcore.LevelEnabler, notifier *gobrake.Notifier) (*Core, error) {
@@ -31,6 +33,12 @@ func (core *Core) SetDepth(depth int) {
 	core.depth = depth
 }
 
+// SetBreadcrumbLevel enables recording of log entries that are not sent to
+// Airbrake as notifier breadcrumbs. Entries below enab are dropped.
+func (core *Core) SetBreadcrumbLevel(enab zapcore.LevelEnabler) {
+	core.breadcrumbLevel = enab
+}
+
 func (core *Core) With(fields []zapcore.Field) zapcore.Core {
 	coreFields := make(map[string]interface{}, len(core.coreFields)+len(fields))
 	for k, v := range core.coreFields {
@@ -52,6 +60,13 @@ func (core *Core) With(fields []zapcore.Field) zapcore.Core {
 }
 
 func (core *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !core.LevelEnabler.Enabled(entry.Level) {
+		if core.breadcrumbLevel != nil && core.breadcrumbLevel.Enabled(entry.Level) {
+			core.addBreadcrumb(entry, fields)
+		}
+		return nil
+	}
+
 	parameters := make(map[string]interface{})
 	notice := gobrake.NewNotice(entry.Message, nil, core.depth)
 	for key, parameter := range core.coreFields {
@@ -73,6 +88,40 @@ func (core *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
 	return nil
 }
 
+func (core *Core) addBreadcrumb(entry zapcore.Entry, fields []zapcore.Field) {
+	var data map[string]interface{}
+	if len(core.coreFields) > 0 || len(fields) > 0 {
+		data = make(map[string]interface{}, len(core.coreFields)+len(fields))
+		for k, v := range core.coreFields {
+			data[k] = v
+		}
+
+		encoder := zapcore.NewMapObjectEncoder()
+		for _, field := range fields {
+			field.AddTo(encoder)
+		}
+		for k, v := range encoder.Fields {
+			data[k] = v
+		}
+	}
+	core.Notifier.Breadcrumbs.Add(gobrake.Breadcrumb{
+		Timestamp: entry.Time,
+		Category:  gobrake.BreadcrumbLog,
+		Message:   entry.Message,
+		Level:     entry.Level.String(),
+		Data:      data,
+	})
+}
+
+// Enabled reports whether entries at lvl are sent to Airbrake or recorded
+// as breadcrumbs.
+func (core *Core) Enabled(lvl zapcore.Level) bool {
+	if core.LevelEnabler.Enabled(lvl) {
+		return true
+	}
+	return core.breadcrumbLevel != nil && core.breadcrumbLevel.Enabled(lvl)
+}
+
 func (core *Core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
 	if core.Enabled(entry.Level) {
 		return checked.AddCore(entry, core)
@@ -0,0 +1,71 @@
+package zap
+
+import (
+	"testing"
+
+	"github.com/airbrake/gobrake/v5"
+	"github.com/airbrake/gobrake/v5/internal/testutil"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCoreBreadcrumbs(t *testing.T) {
+	notifier := testutil.NewNotifier(t)
+	core, err := NewCore(zapcore.ErrorLevel, notifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.SetBreadcrumbLevel(zapcore.InfoLevel)
+
+	logger := uberzap.New(core).With(uberzap.String("core", "field"))
+	logger.Debug("dropped")
+	logger.Info("hello", uberzap.Int("entry", 1))
+
+	bcs := notifier.Breadcrumbs.All()
+	if len(bcs) != 1 {
+		t.Fatalf("got %d breadcrumbs, wanted 1", len(bcs))
+	}
+	bc := bcs[0]
+	if bc.Message != "hello" || bc.Level != "info" || bc.Category != gobrake.BreadcrumbLog {
+		t.Fatalf("got %+v", bc)
+	}
+	if bc.Data["core"] != "field" {
+		t.Fatalf("got core field %v, wanted field", bc.Data["core"])
+	}
+	if bc.Data["entry"] != int64(1) {
+		t.Fatalf("got entry field %v, wanted 1", bc.Data["entry"])
+	}
+}
+
+func TestCoreBreadcrumbsDisabled(t *testing.T) {
+	notifier := testutil.NewNotifier(t)
+	core, err := NewCore(zapcore.ErrorLevel, notifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uberzap.New(core).Info("hello")
+
+	if bcs := notifier.Breadcrumbs.All(); len(bcs) != 0 {
+		t.Fatalf("got %d breadcrumbs, wanted 0", len(bcs))
+	}
+}
+
+func TestCoreWriteBelowBreadcrumbLevel(t *testing.T) {
+	notifier := testutil.NewNotifier(t)
+	core, err := NewCore(zapcore.ErrorLevel, notifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.SetBreadcrumbLevel(zapcore.InfoLevel)
+
+	// Write is called without Check, e.g. by wrapping cores.
+	err = core.Write(zapcore.Entry{Level: zapcore.DebugLevel, Message: "dropped"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bcs := notifier.Breadcrumbs.All(); len(bcs) != 0 {
+		t.Fatalf("got %d breadcrumbs, wanted 0", len(bcs))
+	}
+}
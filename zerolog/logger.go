@@ -0,0 +1,32 @@
+package zerolog
+
+import (
+	"github.com/airbrake/gobrake/v5"
+	"github.com/rs/zerolog"
+)
+
+type logger struct {
+	l zerolog.Logger
+}
+
+// NewLogger returns gobrake.Logger that writes notifier messages to l.
+// Use it as NotifierOptions.Logger.
+func NewLogger(l zerolog.Logger) gobrake.Logger {
+	return &logger{l: l}
+}
+
+func (l *logger) Debug(msg string, keyvals ...interface{}) {
+	l.l.Debug().Fields(keyvals).Msg(msg)
+}
+
+func (l *logger) Info(msg string, keyvals ...interface{}) {
+	l.l.Info().Fields(keyvals).Msg(msg)
+}
+
+func (l *logger) Warn(msg string, keyvals ...interface{}) {
+	l.l.Warn().Fields(keyvals).Msg(msg)
+}
+
+func (l *logger) Error(msg string, keyvals ...interface{}) {
+	l.l.Error().Fields(keyvals).Msg(msg)
+}
@@ -0,0 +1,39 @@
+package zerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(zerolog.New(buf))
+
+	l.Debug("debug message", "key", 1)
+	l.Info("info message", "key", 2)
+	l.Warn("warn message", "key", 3)
+	l.Error("error message", "key", 4)
+
+	dec := json.NewDecoder(buf)
+	for i, level := range []string{"debug", "info", "warn", "error"} {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry["level"] != level {
+			t.Fatalf("got level %v, wanted %s", entry["level"], level)
+		}
+		if want := level + " message"; entry["message"] != want {
+			t.Fatalf("got message %v, wanted %q", entry["message"], want)
+		}
+		if entry["key"] != float64(i+1) {
+			t.Fatalf("got key=%v, wanted %d", entry["key"], i+1)
+		}
+	}
+	if dec.More() {
+		t.Fatal("got more entries than wanted")
+	}
+}
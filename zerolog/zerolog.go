@@ -14,6 +14,9 @@ import (
 type WriteCloser struct {
 	Gobrake *gobrake.Notifier
 	depth   int
+
+	breadcrumbs     bool
+	breadcrumbLevel zerolog.Level
 }
 
 // Validates the WriteCloser matches the io.WriteCloser interface
@@ -32,6 +35,13 @@ func (w *WriteCloser) SetDepth(depth int) {
 	w.depth = depth
 }
 
+// SetBreadcrumbLevel enables recording of non-error log entries at or above
+// level as notifier breadcrumbs instead of dropping them.
+func (w *WriteCloser) SetBreadcrumbLevel(level zerolog.Level) {
+	w.breadcrumbs = true
+	w.breadcrumbLevel = level
+}
+
 // Write parses the log data and sends off error notices to airbrake
 func (w *WriteCloser) Write(data []byte) (int, error) {
 	lvl, err := jsonparser.GetUnsafeString(data, zerolog.LevelFieldName)
@@ -40,6 +50,7 @@ func (w *WriteCloser) Write(data []byte) (int, error) {
 	}
 
 	if lvl != zerolog.ErrorLevel.String() {
+		w.addBreadcrumb(lvl, data)
 		return len(data), nil
 	}
 
@@ -96,6 +107,31 @@ func (w *WriteCloser) Write(data []byte) (int, error) {
 	return len(data), nil
 }
 
+func (w *WriteCloser) addBreadcrumb(lvl string, data []byte) {
+	if !w.breadcrumbs || w.Gobrake == nil {
+		return
+	}
+	level, err := zerolog.ParseLevel(lvl)
+	if err != nil || level < w.breadcrumbLevel {
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return
+	}
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.LevelFieldName)
+
+	w.Gobrake.Breadcrumbs.Add(gobrake.Breadcrumb{
+		Category: gobrake.BreadcrumbLog,
+		Message:  msg,
+		Level:    lvl,
+		Data:     fields,
+	})
+}
+
 // Close flushes any remaining notices left in gobrake queue
 func (w *WriteCloser) Close() error {
 	w.Gobrake.Flush()
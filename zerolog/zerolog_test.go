@@ -0,0 +1,49 @@
+package zerolog
+
+import (
+	"testing"
+
+	"github.com/airbrake/gobrake/v5"
+	"github.com/airbrake/gobrake/v5/internal/testutil"
+	"github.com/rs/zerolog"
+)
+
+func TestWriteCloserBreadcrumbs(t *testing.T) {
+	notifier := testutil.NewNotifier(t)
+	w, err := New(notifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetBreadcrumbLevel(zerolog.InfoLevel)
+
+	logger := zerolog.New(w)
+	logger.Debug().Msg("dropped")
+	logger.Info().Str("foo", "bar").Msg("hello")
+
+	bcs := notifier.Breadcrumbs.All()
+	if len(bcs) != 1 {
+		t.Fatalf("got %d breadcrumbs, wanted 1", len(bcs))
+	}
+	bc := bcs[0]
+	if bc.Message != "hello" || bc.Level != "info" || bc.Category != gobrake.BreadcrumbLog {
+		t.Fatalf("got %+v", bc)
+	}
+	if len(bc.Data) != 1 || bc.Data["foo"] != "bar" {
+		t.Fatalf("got data %v, wanted map[foo:bar]", bc.Data)
+	}
+}
+
+func TestWriteCloserBreadcrumbsDisabled(t *testing.T) {
+	notifier := testutil.NewNotifier(t)
+	w, err := New(notifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := zerolog.New(w)
+	logger.Info().Msg("hello")
+
+	if bcs := notifier.Breadcrumbs.All(); len(bcs) != 0 {
+		t.Fatalf("got %d breadcrumbs, wanted 0", len(bcs))
+	}
+}